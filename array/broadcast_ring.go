@@ -0,0 +1,227 @@
+package array
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var ErrOverrun = errors.New("broadcast reader overrun")
+
+// OverrunError 读者落后太多，未读数据已经被覆盖，Lost为丢失的字节数
+// errors.Is(err, ErrOverrun)可以判断是否是这个错误
+type OverrunError struct {
+	Lost int64
+}
+
+func (e *OverrunError) Error() string {
+	return fmt.Sprintf("broadcast reader overrun: lost %d bytes", e.Lost)
+}
+
+func (e *OverrunError) Is(target error) bool {
+	return target == ErrOverrun
+}
+
+// BroadcastRing 一写多读的广播ring buffer，每个读者按自己的进度读取全部数据
+// 原理：底层RingBuf工作在覆盖模式，虚拟下标单调递增，每个读者只记录自己的读游标
+// 游标小于begin说明数据已经被覆盖，读者下一次Read时得到OverrunError并跳到begin继续读
+// 阻塞模式下写者不覆盖任何读者的未读数据，空间由最慢的读者决定，没有读者时直接覆盖
+// 等待和唤醒方式与SyncRingBuf相同，并发安全
+type BroadcastRing struct {
+	mu       sync.Mutex
+	rb       RingBuf
+	blocking bool // 写满时的行为，false覆盖最旧的数据，true等待最慢的读者
+	closed   bool
+	readers  map[*BroadcastReader]struct{}
+	waiters  int
+	changed  chan struct{}
+}
+
+// BroadcastReader BroadcastRing的一个订阅者
+type BroadcastReader struct {
+	ring   *BroadcastRing
+	cursor int64 // 下一个要读的虚拟下标
+	closed bool
+}
+
+// NewBroadcastRing constructor
+func NewBroadcastRing(size int) *BroadcastRing {
+	return &BroadcastRing{
+		rb:      NewRingBuf(size),
+		readers: make(map[*BroadcastReader]struct{}),
+		changed: make(chan struct{}),
+	}
+}
+
+// SetBlocking 设置写满时的行为，默认覆盖最旧的数据，开启后写者等待最慢的读者
+func (b *BroadcastRing) SetBlocking(on bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blocking = on
+	b.notify()
+}
+
+// wait 等待状态变化，调用方持有锁，返回时重新持有锁
+func (b *BroadcastRing) wait(ctx context.Context) error {
+	ch := b.changed
+	b.waiters++
+	b.mu.Unlock()
+	var err error
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	b.mu.Lock()
+	b.waiters--
+	return err
+}
+
+// notify 唤醒所有等待者，调用方持有锁
+func (b *BroadcastRing) notify() {
+	if b.waiters == 0 {
+		return
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Subscribe 新建一个读者，从当前的End开始读，只能读到之后写入的数据
+func (b *BroadcastRing) Subscribe() *BroadcastReader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := &BroadcastReader{ring: b, cursor: b.rb.End()}
+	b.readers[r] = struct{}{}
+	return r
+}
+
+// free 不覆盖任何读者未读数据时还能写入的长度，调用方持有锁
+func (b *BroadcastRing) free() int {
+	size := len(b.rb.data)
+	if !b.blocking {
+		return size
+	}
+	slowest := b.rb.End()
+	for r := range b.readers {
+		slowest = min(slowest, max(r.cursor, b.rb.Begin()))
+	}
+	return size - int(b.rb.End()-slowest)
+}
+
+// Write 写入全部数据，CloseWrite之后返回io.ErrClosedPipe，阻塞模式下空间不足时等待
+func (b *BroadcastRing) Write(p []byte) (int, error) {
+	return b.WriteContext(context.Background(), p)
+}
+
+// WriteContext 写入全部数据，阻塞模式下空间不足时等待直到有空间或者ctx结束，返回已写入的长度
+func (b *BroadcastRing) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.rb.data) == 0 && len(p) > 0 {
+		return 0, ErrFull
+	}
+	for n < len(p) {
+		if b.closed {
+			return n, io.ErrClosedPipe
+		}
+		free := b.free()
+		if free == 0 {
+			if err := b.wait(ctx); err != nil {
+				return n, err
+			}
+			continue
+		}
+		m, _ := b.rb.Write(p[n:min(len(p), n+free)])
+		n += m
+		b.notify()
+	}
+	return n, nil
+}
+
+// CloseWrite 结束写入，读者读完剩余数据后得到io.EOF
+func (b *BroadcastRing) CloseWrite() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.notify()
+	return nil
+}
+
+// Begin 最旧数据的偏移量
+func (b *BroadcastRing) Begin() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rb.Begin()
+}
+
+// End 下一个写入字节的偏移量
+func (b *BroadcastRing) End() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rb.End()
+}
+
+// Read 读出数据，没有数据时阻塞，CloseWrite之后读完剩余数据返回io.EOF
+// 未读数据已经被覆盖时返回*OverrunError，游标跳到最旧的数据，下一次Read继续读
+func (r *BroadcastReader) Read(p []byte) (int, error) {
+	return r.ReadContext(context.Background(), p)
+}
+
+// ReadContext 读出数据，没有数据时阻塞直到有数据、CloseWrite或者ctx结束
+func (r *BroadcastReader) ReadContext(ctx context.Context, p []byte) (int, error) {
+	b := r.ring
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		if r.closed {
+			return 0, io.ErrClosedPipe
+		}
+		if begin := b.rb.Begin(); r.cursor < begin {
+			lost := begin - r.cursor
+			r.cursor = begin
+			return 0, &OverrunError{Lost: lost}
+		}
+		if len(p) == 0 {
+			return 0, nil
+		}
+		if r.cursor < b.rb.End() {
+			n, _ := b.rb.ReadAt(p, r.cursor)
+			r.cursor += int64(n)
+			b.notify()
+			return n, nil
+		}
+		if b.closed {
+			return 0, io.EOF
+		}
+		if err := b.wait(ctx); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Offset 下一个要读的偏移量
+func (r *BroadcastReader) Offset() int64 {
+	r.ring.mu.Lock()
+	defer r.ring.mu.Unlock()
+	return r.cursor
+}
+
+// Len 未读数据的长度，包括已经被覆盖的部分
+func (r *BroadcastReader) Len() int {
+	r.ring.mu.Lock()
+	defer r.ring.mu.Unlock()
+	return int(r.ring.rb.End() - r.cursor)
+}
+
+// Close 取消订阅，阻塞模式下写者不再等待这个读者
+func (r *BroadcastReader) Close() error {
+	b := r.ring
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r.closed = true
+	delete(b.readers, r)
+	b.notify()
+	return nil
+}
@@ -0,0 +1,101 @@
+package array
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcastRingOverrun(t *testing.T) {
+	b := NewBroadcastRing(8)
+	fast, slow := b.Subscribe(), b.Subscribe()
+	p := make([]byte, 8)
+	b.Write([]byte("abcdef"))
+	if n, _ := fast.Read(p); string(p[:n]) != "abcdef" {
+		t.Fatalf("fast read got %q", p[:n])
+	}
+	b.Write([]byte("ghijkl"))
+	if n, _ := fast.Read(p); string(p[:n]) != "ghijkl" {
+		t.Fatalf("fast read got %q", p[:n])
+	}
+
+	_, err := slow.Read(p)
+	var overrun *OverrunError
+	if !errors.Is(err, ErrOverrun) || !errors.As(err, &overrun) || overrun.Lost != 4 {
+		t.Fatalf("slow read expect overrun lost 4, got %v", err)
+	}
+	if n, _ := slow.Read(p); string(p[:n]) != "efghijkl" {
+		t.Errorf("slow read after overrun got %q", p[:n])
+	}
+
+	late := b.Subscribe()
+	b.Write([]byte("m"))
+	b.CloseWrite()
+	if n, _ := late.Read(p); string(p[:n]) != "m" {
+		t.Errorf("late read got %q", p[:n])
+	}
+	if _, err := late.Read(p); err != io.EOF {
+		t.Errorf("read after close expect EOF, got %v", err)
+	}
+	if _, err := b.Write([]byte("n")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write after close expect ErrClosedPipe, got %v", err)
+	}
+}
+
+func TestBroadcastRingBlocking(t *testing.T) {
+	b := NewBroadcastRing(4)
+	b.SetBlocking(true)
+	r := b.Subscribe()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if n, err := b.WriteContext(ctx, []byte("abcdef")); n != 4 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("write expect 4, deadline, got %d, %v", n, err)
+	}
+	p := make([]byte, 2)
+	r.Read(p)
+	if n, err := b.Write([]byte("ef")); n != 2 || err != nil {
+		t.Fatalf("write after read got %d, %v", n, err)
+	}
+
+	// 关闭读者之后写者不再等待
+	done := make(chan struct{})
+	go func() {
+		b.Write([]byte("ghij"))
+		close(done)
+	}()
+	r.Close()
+	<-done
+	if _, err := r.Read(p); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("read closed reader expect ErrClosedPipe, got %v", err)
+	}
+}
+
+func TestBroadcastRingConcurrent(t *testing.T) {
+	b := NewBroadcastRing(64)
+	b.SetBlocking(true)
+	src := make([]byte, 1<<16)
+	for i := range src {
+		src[i] = byte(i * 7)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		r := b.Subscribe()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := io.ReadAll(r)
+			if err != nil || !bytes.Equal(got, src) {
+				t.Errorf("reader got %d bytes, %v", len(got), err)
+			}
+		}()
+	}
+	for i := 0; i < len(src); i += 100 {
+		b.Write(src[i:min(i+100, len(src))])
+	}
+	b.CloseWrite()
+	wg.Wait()
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin || dragonfly || freebsd || openbsd
+
+package array
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// msync 把映射内存刷到磁盘
+func msync(_ *os.File, b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
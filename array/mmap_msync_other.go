@@ -0,0 +1,10 @@
+//go:build unix && !(linux || darwin || dragonfly || freebsd || openbsd)
+
+package array
+
+import "os"
+
+// msync 标准库没有提供msync系统调用号的平台，页缓存和映射内存是同一份，用fsync刷整个文件
+func msync(f *os.File, _ []byte) error {
+	return f.Sync()
+}
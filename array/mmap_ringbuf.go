@@ -0,0 +1,262 @@
+//go:build unix
+
+package array
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"syscall"
+)
+
+var (
+	ErrMmapCorrupt = errors.New("mmap ring buffer header is corrupt")
+	ErrMmapSize    = errors.New("mmap ring buffer size mismatch")
+)
+
+const (
+	mmapMagic      = "DSRB"
+	mmapVersion    = 1
+	mmapHeaderSize = 4096 // 头部占一个页，数据区从页边界开始
+	mmapSlotSize   = 64
+	mmapSlotLen    = 52 // magic(4) version(4) seq(8) size(8) begin(8) end(8) index(8) crc32(4)
+)
+
+// MmapRingBuf 基于内存映射文件的RingBuf，进程崩溃或重启后可以恢复全部数据和偏移量
+// 文件布局：4KB头部 + size字节数据区，数据区直接作为RingBuf的底层数组
+// 头部有两个槽位，每次提交交替写入并递增seq，每个槽位带crc32，打开时选择校验通过且seq最大的槽位
+// 写头部时崩溃最多损坏一个槽位，另一个槽位还是上一次提交的完整状态
+// 每次修改都会更新映射内存中的头部，MAP_SHARED的内存在进程崩溃时不会丢失；需要防止机器掉电时调用Sync刷盘
+// 非并发安全
+type MmapRingBuf struct {
+	rb   RingBuf
+	file *os.File
+	mem  []byte // 整个映射区域
+	seq  uint64
+}
+
+// OpenMmapRingBuf 打开或创建path，新文件的数据区为size字节
+// 已存在的文件按头部恢复，size为0时使用文件中记录的大小，否则必须和文件一致
+func OpenMmapRingBuf(path string, size int) (*MmapRingBuf, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	m, err := openMmapRingBuf(f, size)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+func openMmapRingBuf(f *os.File, size int) (*MmapRingBuf, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// 创建时先写入头部并落盘，再扩展到完整长度，文件不到一个头部的长度说明创建还没完成，可以重新初始化
+	// 达到头部长度的文件一定有写好的头部，槽位都无效时报错，不会清掉已有的数据
+	if fi.Size() < mmapHeaderSize {
+		if size <= 0 {
+			return nil, ErrMmapSize
+		}
+		if err := createMmapFile(f, size); err != nil {
+			return nil, err
+		}
+		fi, err = f.Stat()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if fi.Size() <= mmapHeaderSize {
+		return nil, ErrMmapCorrupt
+	}
+	mem, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	m := &MmapRingBuf{file: f, mem: mem}
+	m.rb.data = mem[mmapHeaderSize:]
+	if err := m.recover(size); err != nil {
+		syscall.Munmap(mem)
+		return nil, err
+	}
+	return m, nil
+}
+
+// createMmapFile 写入第一个头部并落盘，然后扩展文件到头部加size字节
+func createMmapFile(f *os.File, size int) error {
+	var head [2 * mmapSlotSize]byte
+	encodeSlot(head[mmapSlotSize:], 1, int64(size), 0, 0, 0)
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(head[:], 0); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Truncate(int64(mmapHeaderSize + size)); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// slot 第i个头部槽位
+func (m *MmapRingBuf) slot(i uint64) []byte {
+	off := int(i%2) * mmapSlotSize
+	return m.mem[off : off+mmapSlotLen]
+}
+
+// recover 从两个槽位中选出有效且最新的头部恢复偏移量
+func (m *MmapRingBuf) recover(size int) error {
+	var best []byte
+	var bestSeq uint64
+	for i := uint64(0); i < 2; i++ {
+		s := m.slot(i)
+		if string(s[:4]) != mmapMagic || binary.LittleEndian.Uint32(s[4:]) != mmapVersion {
+			continue
+		}
+		if crc32.ChecksumIEEE(s[:mmapSlotLen-4]) != binary.LittleEndian.Uint32(s[mmapSlotLen-4:]) {
+			continue
+		}
+		if seq := binary.LittleEndian.Uint64(s[8:]); best == nil || seq > bestSeq {
+			best, bestSeq = s, seq
+		}
+	}
+	if best == nil {
+		return ErrMmapCorrupt
+	}
+	dataSize := int64(binary.LittleEndian.Uint64(best[16:]))
+	if dataSize != int64(len(m.rb.data)) || size > 0 && int64(size) != dataSize {
+		return ErrMmapSize
+	}
+	begin := int64(binary.LittleEndian.Uint64(best[24:]))
+	end := int64(binary.LittleEndian.Uint64(best[32:]))
+	index := int64(binary.LittleEndian.Uint64(best[40:]))
+	if begin < 0 || end < begin || end-begin > dataSize || index < 0 || index >= max(dataSize, 1) {
+		return ErrMmapCorrupt
+	}
+	m.seq = bestSeq
+	m.rb.begin, m.rb.end, m.rb.index = begin, end, int(index)
+	return nil
+}
+
+// commit 把当前偏移量写入下一个槽位
+func (m *MmapRingBuf) commit() {
+	m.seq++
+	encodeSlot(m.slot(m.seq), m.seq, int64(len(m.rb.data)), m.rb.begin, m.rb.end, m.rb.index)
+}
+
+// encodeSlot 编码一个头部槽位
+func encodeSlot(s []byte, seq uint64, size, begin, end int64, index int) {
+	copy(s, mmapMagic)
+	binary.LittleEndian.PutUint32(s[4:], mmapVersion)
+	binary.LittleEndian.PutUint64(s[8:], seq)
+	binary.LittleEndian.PutUint64(s[16:], uint64(size))
+	binary.LittleEndian.PutUint64(s[24:], uint64(begin))
+	binary.LittleEndian.PutUint64(s[32:], uint64(end))
+	binary.LittleEndian.PutUint64(s[40:], uint64(index))
+	binary.LittleEndian.PutUint32(s[mmapSlotLen-4:], crc32.ChecksumIEEE(s[:mmapSlotLen-4]))
+}
+
+// Write 从尾部写入，写满后覆盖最旧的数据
+// 先提交推进后的begin再覆盖数据，最后提交新的end，任何时刻崩溃头部都不会指向被覆盖了一半的数据
+func (m *MmapRingBuf) Write(p []byte) (n int, err error) {
+	if len(p) > len(m.rb.data) {
+		return 0, ErrOutOfRange
+	}
+	if begin := m.rb.end + int64(len(p)) - int64(len(m.rb.data)); begin > m.rb.begin {
+		m.rb.begin = begin
+		m.commit()
+	}
+	n, err = m.rb.Write(p)
+	m.commit()
+	return
+}
+
+// Read 从头部读出并消费数据
+func (m *MmapRingBuf) Read(p []byte) (n int, err error) {
+	n, err = m.rb.Read(p)
+	if n > 0 {
+		m.commit()
+	}
+	return
+}
+
+// Discard 跳过接下来的n个字节，n为负数时返回ErrNegativeCount，不提交头部
+func (m *MmapRingBuf) Discard(n int) (int, error) {
+	n, err := m.rb.Discard(n)
+	if n > 0 {
+		m.commit()
+	}
+	return n, err
+}
+
+// ReadAt 按虚拟偏移量读取，不消费数据
+func (m *MmapRingBuf) ReadAt(p []byte, offset int64) (int, error) {
+	return m.rb.ReadAt(p, offset)
+}
+
+// Peek 返回接下来的n个字节的拷贝，不消费数据
+func (m *MmapRingBuf) Peek(n int) ([]byte, error) {
+	return m.rb.Peek(n)
+}
+
+// WriteTo 实现io.WriterTo，把未读数据全部写入w并消费掉
+func (m *MmapRingBuf) WriteTo(w io.Writer) (int64, error) {
+	n, err := m.rb.WriteTo(w)
+	if n > 0 {
+		m.commit()
+	}
+	return n, err
+}
+
+// Reset 清空数据，偏移量从0重新开始
+func (m *MmapRingBuf) Reset() {
+	m.rb.Reset()
+	m.commit()
+}
+
+func (m *MmapRingBuf) Size() int64 {
+	return m.rb.Size()
+}
+
+func (m *MmapRingBuf) Begin() int64 {
+	return m.rb.Begin()
+}
+
+func (m *MmapRingBuf) End() int64 {
+	return m.rb.End()
+}
+
+// Len 未读数据的长度
+func (m *MmapRingBuf) Len() int {
+	return m.rb.Len()
+}
+
+// Sync 刷盘，先刷数据区再刷头部，掉电后头部不会指向还没落盘的数据
+func (m *MmapRingBuf) Sync() error {
+	if err := msync(m.file, m.mem[mmapHeaderSize:]); err != nil {
+		return err
+	}
+	return msync(m.file, m.mem[:mmapHeaderSize])
+}
+
+// Close 刷盘并解除映射
+func (m *MmapRingBuf) Close() error {
+	err := m.Sync()
+	if e := syscall.Munmap(m.mem); err == nil {
+		err = e
+	}
+	m.mem = nil
+	m.rb.data = nil
+	if e := m.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+//go:build unix
+
+package array
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMmapRingBuf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	m, err := OpenMmapRingBuf(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Write([]byte("0123456789"))
+	m.Write([]byte("abcdefghij")) // 覆盖最旧的4个字节
+	p := make([]byte, 2)
+	m.Read(p)
+	if string(p) != "45" {
+		t.Errorf("read got %q", p)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = OpenMmapRingBuf(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Size() != 16 || m.Begin() != 6 || m.End() != 20 {
+		t.Fatalf("reopen got size %d [%d, %d)", m.Size(), m.Begin(), m.End())
+	}
+	got, _ := m.Peek(m.Len())
+	if string(got) != "6789abcdefghij" {
+		t.Errorf("reopen got %q", got)
+	}
+	m.Write([]byte("XY"))
+	got, _ = m.Peek(m.Len())
+	if string(got) != "6789abcdefghijXY" {
+		t.Errorf("write after reopen got %q", got)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ = OpenMmapRingBuf(path, 0)
+	if n, err := m.Discard(-5); n != 0 || !errors.Is(err, ErrNegativeCount) || m.Begin() != 6 {
+		t.Errorf("discard -5 expect ErrNegativeCount, got %d, %v, begin %d", n, err, m.Begin())
+	}
+	m.Close()
+
+	if _, err := OpenMmapRingBuf(path, 32); !errors.Is(err, ErrMmapSize) {
+		t.Errorf("open with other size expect ErrMmapSize, got %v", err)
+	}
+}
+
+func TestMmapRingBufTornHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	m, err := OpenMmapRingBuf(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Write([]byte("hello"))
+	m.Write([]byte("world"))
+	latest := m.seq
+	m.Close()
+
+	// 模拟写最新槽位时崩溃，应该回退到上一次提交
+	f, _ := os.OpenFile(path, os.O_RDWR, 0)
+	f.WriteAt([]byte{0xff, 0xff}, int64(latest%2)*mmapSlotSize+30)
+	f.Close()
+	m, err = OpenMmapRingBuf(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := m.Peek(m.Len())
+	if string(got) != "hello" {
+		t.Errorf("recover got %q", got)
+	}
+	m.Close()
+
+	// 两个槽位都损坏时无法恢复
+	f, _ = os.OpenFile(path, os.O_RDWR, 0)
+	f.WriteAt([]byte{0xff}, 10)
+	f.WriteAt([]byte{0xff}, mmapSlotSize+10)
+	f.Close()
+	if _, err := OpenMmapRingBuf(path, 16); !errors.Is(err, ErrMmapCorrupt) {
+		t.Errorf("expect ErrMmapCorrupt, got %v", err)
+	}
+}
+
+func TestMmapRingBufCrashOnCreate(t *testing.T) {
+	// 模拟创建时写了一半头部就崩溃，文件不到一个头部的长度，重新初始化
+	path := filepath.Join(t.TempDir(), "ring")
+	if err := os.WriteFile(path, []byte(mmapMagic), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMmapRingBuf(path, 0); !errors.Is(err, ErrMmapSize) {
+		t.Errorf("reinitialize without size expect ErrMmapSize, got %v", err)
+	}
+	m, err := OpenMmapRingBuf(path, 16)
+	if err != nil {
+		t.Fatalf("open half-created file expect reinitialize, got %v", err)
+	}
+	if m.Size() != 16 || m.Len() != 0 {
+		t.Errorf("reinitialize got size %d len %d", m.Size(), m.Len())
+	}
+	m.Write([]byte("abc"))
+	m.Close()
+	m, err = OpenMmapRingBuf(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Peek(m.Len()); string(got) != "abc" {
+		t.Errorf("reopen got %q", got)
+	}
+	m.Close()
+}
+
+func TestMmapRingBufKeepUnknownFile(t *testing.T) {
+	// 完整长度但头部全是0的文件不是这里创建的，报错而不是清空
+	path := filepath.Join(t.TempDir(), "ring")
+	content := make([]byte, mmapHeaderSize+16)
+	copy(content[mmapHeaderSize:], "precious")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMmapRingBuf(path, 16); !errors.Is(err, ErrMmapCorrupt) {
+		t.Errorf("zero header expect ErrMmapCorrupt, got %v", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, content) {
+		t.Errorf("file should be left untouched")
+	}
+}
@@ -0,0 +1,157 @@
+package array
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"iter"
+)
+
+var (
+	ErrRecordTooLarge = errors.New("record is larger than ring buffer")
+	ErrCorruptRecord  = errors.New("record is corrupt")
+)
+
+// RecordRing 基于RingBuf的定长内存日志，可以用作事件流的有界重放缓冲
+// 原理：每条记录为 varint长度 | crc32(4B，大端) | 数据，按顺序追加到RingBuf
+// 记录的偏移量就是RingBuf单调递增的虚拟下标，一直有效，直到记录被覆盖
+// 空间不足时从最旧的记录开始整条丢弃，所以begin总是落在记录边界上，不会出现被覆盖了一半的记录
+// 非并发安全
+type RecordRing struct {
+	rb    RingBuf
+	count int // 保留的记录数量
+}
+
+// RecordCursor 按顺序读取RecordRing的游标，每个游标有自己的位置和错误，多个游标可以同时遍历
+type RecordCursor struct {
+	ring   *RecordRing
+	offset int64 // 下一条要读的记录
+	err    error // 遍历出错的原因
+}
+
+// NewRecordRing constructor
+func NewRecordRing(size int) *RecordRing {
+	r := &RecordRing{rb: NewRingBuf(size)}
+	r.rb.SetShortWrite(true)
+	return r
+}
+
+// Begin 最旧记录的偏移量
+func (r *RecordRing) Begin() int64 {
+	return r.rb.Begin()
+}
+
+// End 下一条记录的偏移量
+func (r *RecordRing) End() int64 {
+	return r.rb.End()
+}
+
+// Len 保留的记录数量
+func (r *RecordRing) Len() int {
+	return r.count
+}
+
+// header 读取offset处的记录头，返回数据长度、crc和头部长度
+func (r *RecordRing) header(offset int64) (size int, sum uint32, headerLen int, err error) {
+	var buf [binary.MaxVarintLen64 + 4]byte
+	n, _ := r.rb.ReadAt(buf[:], offset)
+	v, vn := binary.Uvarint(buf[:n])
+	if vn <= 0 || vn+4 > n || v > uint64(r.rb.Size()) {
+		return 0, 0, 0, ErrCorruptRecord
+	}
+	return int(v), binary.BigEndian.Uint32(buf[vn:]), vn + 4, nil
+}
+
+// Append 追加一条记录，返回记录的偏移量，空间不足时丢弃最旧的记录
+func (r *RecordRing) Append(record []byte) (offset int64, err error) {
+	var head [binary.MaxVarintLen64 + 4]byte
+	n := binary.PutUvarint(head[:], uint64(len(record)))
+	binary.BigEndian.PutUint32(head[n:], crc32.ChecksumIEEE(record))
+	n += 4
+	if int64(n+len(record)) > r.rb.Size() {
+		return 0, ErrRecordTooLarge
+	}
+	for r.rb.Free() < n+len(record) {
+		size, _, headerLen, err := r.header(r.rb.Begin())
+		if err != nil {
+			return 0, err
+		}
+		r.rb.Discard(headerLen + size)
+		r.count--
+	}
+	offset = r.rb.End()
+	r.rb.Write(head[:n])
+	r.rb.Write(record)
+	r.count++
+	return offset, nil
+}
+
+// ReadRecord 读取offset处的记录，返回记录和下一条记录的偏移量
+// offset已经被覆盖时返回ErrOutOfRange，offset等于End时返回io.EOF，offset不在记录边界上时通常返回ErrCorruptRecord
+func (r *RecordRing) ReadRecord(offset int64) (record []byte, next int64, err error) {
+	if offset == r.rb.End() {
+		return nil, offset, io.EOF
+	}
+	if offset < r.rb.Begin() || offset > r.rb.End() {
+		return nil, offset, ErrOutOfRange
+	}
+	size, sum, headerLen, err := r.header(offset)
+	if err != nil {
+		return nil, offset, err
+	}
+	next = offset + int64(headerLen+size)
+	if next > r.rb.End() {
+		return nil, offset, ErrCorruptRecord
+	}
+	record = make([]byte, size)
+	r.rb.ReadAt(record, offset+int64(headerLen))
+	if crc32.ChecksumIEEE(record) != sum {
+		return nil, offset, ErrCorruptRecord
+	}
+	return record, next, nil
+}
+
+// Cursor 返回从offset开始读取的游标
+func (r *RecordRing) Cursor(offset int64) *RecordCursor {
+	return &RecordCursor{ring: r, offset: offset}
+}
+
+// Records 从游标位置开始按顺序遍历记录，返回偏移量和记录，读到End或者出错时结束
+// 遍历过程中可以继续Append，新追加的记录也会被遍历到；提前结束时游标停在下一条记录上，再次遍历会接着读
+// 遍历结束后通过Err区分正常结束和出错
+func (c *RecordCursor) Records() iter.Seq2[int64, []byte] {
+	return func(yield func(int64, []byte) bool) {
+		for c.err == nil {
+			record, next, err := c.ring.ReadRecord(c.offset)
+			if err != nil {
+				if err != io.EOF {
+					c.err = err
+				}
+				return
+			}
+			offset := c.offset
+			c.offset = next
+			if !yield(offset, record) {
+				return
+			}
+		}
+	}
+}
+
+// Offset 下一条要读的记录的偏移量，读到End之后追加了新记录，可以用同一个游标继续遍历
+func (c *RecordCursor) Offset() int64 {
+	return c.offset
+}
+
+// Err 遍历出错的原因，读到End时为nil
+// 读者落后被覆盖时为ErrOutOfRange，可以从Begin重新开始；记录损坏时为ErrCorruptRecord
+func (c *RecordCursor) Err() error {
+	return c.err
+}
+
+// Reset 清空所有记录，偏移量从0重新开始
+func (r *RecordRing) Reset() {
+	r.rb.Reset()
+	r.count = 0
+}
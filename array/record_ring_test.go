@@ -0,0 +1,168 @@
+package array
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"testing"
+)
+
+func TestRecordRing(t *testing.T) {
+	r := NewRecordRing(64)
+	var offsets []int64
+	for i := 0; i < 20; i++ {
+		off, err := r.Append([]byte(fmt.Sprintf("event-%02d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets = append(offsets, off)
+	}
+	// 每条记录 1字节长度 + 4字节crc + 8字节数据，64字节只能保留4条
+	if r.Len() != 4 || r.Begin() != offsets[16] {
+		t.Fatalf("expect 4 records from %d, got %d from %d", offsets[16], r.Len(), r.Begin())
+	}
+	if _, _, err := r.ReadRecord(offsets[15]); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("overwritten record expect ErrOutOfRange, got %v", err)
+	}
+	rec, next, err := r.ReadRecord(offsets[17])
+	if string(rec) != "event-17" || next != offsets[18] || err != nil {
+		t.Errorf("read 17 got %q, %d, %v", rec, next, err)
+	}
+	if _, _, err := r.ReadRecord(r.End()); err != io.EOF {
+		t.Errorf("read end expect EOF, got %v", err)
+	}
+	if _, _, err := r.ReadRecord(offsets[17] + 1); !errors.Is(err, ErrCorruptRecord) {
+		t.Errorf("read inside record expect ErrCorruptRecord, got %v", err)
+	}
+
+	var got []string
+	for off, rec := range r.Cursor(offsets[18]).Records() {
+		got = append(got, fmt.Sprintf("%d:%s", off, rec))
+	}
+	want := []string{fmt.Sprintf("%d:event-18", offsets[18]), fmt.Sprintf("%d:event-19", offsets[19])}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("records expect %v, got %v", want, got)
+	}
+
+	if _, err := r.Append(make([]byte, 60)); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("append too large expect ErrRecordTooLarge, got %v", err)
+	}
+	// 刚好占满整个缓冲区的记录会挤掉所有旧记录
+	off, err := r.Append(make([]byte, 59))
+	if err != nil || r.Len() != 1 || r.Begin() != off {
+		t.Errorf("append 59 bytes got %d, %v, len %d", off, err, r.Len())
+	}
+	r.Reset()
+	if r.Len() != 0 || r.Begin() != 0 || r.End() != 0 {
+		t.Errorf("reset failed")
+	}
+}
+
+func TestRecordRingVariableSize(t *testing.T) {
+	r := NewRecordRing(1000)
+	var last int64
+	for i := 0; i < 500; i++ {
+		var err error
+		last, err = r.Append(make([]byte, i%150))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 从Begin遍历到最后一条，中间不会遇到损坏的记录
+	n := 0
+	var end int64
+	for off, rec := range r.Cursor(r.Begin()).Records() {
+		n++
+		end = off
+		if len(rec) > 150 {
+			t.Fatalf("bad record at %d", off)
+		}
+	}
+	if n != r.Len() || end != last {
+		t.Errorf("records expect %d ending at %d, got %d ending at %d", r.Len(), last, n, end)
+	}
+}
+
+func TestRecordRingOverrunDuringIteration(t *testing.T) {
+	r := NewRecordRing(64)
+	for i := 0; i < 4; i++ {
+		r.Append([]byte(fmt.Sprintf("event-%02d", i)))
+	}
+	c := r.Cursor(r.Begin())
+	var got []string
+	for _, rec := range c.Records() {
+		got = append(got, string(rec))
+		if len(got) == 1 {
+			// 遍历过程中写入大量记录，把读者后面的记录全部覆盖
+			for i := 4; i < 12; i++ {
+				r.Append([]byte(fmt.Sprintf("event-%02d", i)))
+			}
+		}
+	}
+	if len(got) != 1 || !errors.Is(c.Err(), ErrOutOfRange) {
+		t.Errorf("overrun expect 1 record and ErrOutOfRange, got %v, %v", got, c.Err())
+	}
+
+	// 正常读到End时没有错误，追加记录之后同一个游标接着读
+	c = r.Cursor(r.Begin())
+	for range c.Records() {
+	}
+	if c.Err() != nil || c.Offset() != r.End() {
+		t.Errorf("full iteration expect nil err at end, got %v at %d", c.Err(), c.Offset())
+	}
+	r.Append([]byte("event-12"))
+	got = got[:0]
+	for _, rec := range c.Records() {
+		got = append(got, string(rec))
+	}
+	if fmt.Sprint(got) != "[event-12]" || c.Err() != nil {
+		t.Errorf("resume expect [event-12], got %v, %v", got, c.Err())
+	}
+
+	// 从记录中间开始遍历得到损坏错误
+	c = r.Cursor(r.Begin() + 1)
+	for range c.Records() {
+	}
+	if !errors.Is(c.Err(), ErrCorruptRecord) {
+		t.Errorf("misaligned iteration expect ErrCorruptRecord, got %v", c.Err())
+	}
+}
+
+func TestRecordRingConcurrentCursors(t *testing.T) {
+	r := NewRecordRing(64)
+	for i := 0; i < 4; i++ {
+		r.Append([]byte(fmt.Sprintf("event-%02d", i)))
+	}
+	// 慢游标在快游标遍历的过程中被覆盖，两个游标的错误互不影响
+	slow, fast := r.Cursor(r.Begin()), r.Cursor(r.Begin())
+	next, stop := iter.Pull2(slow.Records())
+	defer stop()
+	if _, rec, ok := next(); !ok || string(rec) != "event-00" {
+		t.Fatalf("slow first record got %q, %v", rec, ok)
+	}
+	n := 0
+	for range fast.Records() {
+		n++
+		if n == 1 {
+			for i := 4; i < 12; i++ {
+				r.Append([]byte(fmt.Sprintf("event-%02d", i)))
+			}
+		}
+	}
+	if !errors.Is(fast.Err(), ErrOutOfRange) {
+		t.Fatalf("fast expect ErrOutOfRange, got %v", fast.Err())
+	}
+	// 快游标从Begin重新开始，正常读到End
+	fast = r.Cursor(r.Begin())
+	n = 0
+	for range fast.Records() {
+		n++
+	}
+	if n != r.Len() || fast.Err() != nil {
+		t.Errorf("fast restart expect %d records and nil err, got %d, %v", r.Len(), n, fast.Err())
+	}
+	if _, _, ok := next(); ok || !errors.Is(slow.Err(), ErrOutOfRange) {
+		t.Errorf("slow expect ErrOutOfRange after fast finished cleanly, got %v, %v", ok, slow.Err())
+	}
+}
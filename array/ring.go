@@ -0,0 +1,160 @@
+package array
+
+import "iter"
+
+// Ring 泛型环形双端队列，两端都可以O(1)插入和删除，支持按下标随机访问
+// 原理：数组长度取2的n次方，head为队首下标，第i个元素在(head+i)&mask，用位运算代替取模
+// 默认写满时容量翻倍；固定容量模式下写满时覆盖另一端的元素，可以用来保存最近的N条记录
+// 非并发安全
+type Ring[T any] struct {
+	buf   []T
+	mask  int
+	head  int // 队首下标
+	size  int
+	fixed bool // 固定容量，写满时覆盖而不是扩容
+}
+
+// roundPow2 向上取整到2的n次方，最小为1
+func roundPow2(n int) int {
+	c := 1
+	for c < n {
+		c <<= 1
+	}
+	return c
+}
+
+// NewRing constructor，写满时自动扩容，capacity为初始容量
+func NewRing[T any](capacity int) *Ring[T] {
+	c := roundPow2(capacity)
+	return &Ring[T]{buf: make([]T, c), mask: c - 1}
+}
+
+// NewFixedRing constructor，容量固定为capacity向上取整到2的n次方，写满时覆盖另一端的元素
+func NewFixedRing[T any](capacity int) *Ring[T] {
+	r := NewRing[T](capacity)
+	r.fixed = true
+	return r
+}
+
+// Len 元素个数
+func (r *Ring[T]) Len() int {
+	return r.size
+}
+
+// Cap 当前容量
+func (r *Ring[T]) Cap() int {
+	return len(r.buf)
+}
+
+// grow 容量翻倍，元素从新数组头部开始按顺序存放
+func (r *Ring[T]) grow() {
+	buf := make([]T, len(r.buf)*2)
+	n := copy(buf, r.buf[r.head:])
+	copy(buf[n:], r.buf[:r.head])
+	r.buf = buf
+	r.mask = len(buf) - 1
+	r.head = 0
+}
+
+// PushBack 插入队尾，固定容量模式下写满时覆盖队首
+func (r *Ring[T]) PushBack(v T) {
+	if r.size == len(r.buf) {
+		if r.fixed {
+			r.buf[r.head] = v
+			r.head = (r.head + 1) & r.mask
+			return
+		}
+		r.grow()
+	}
+	r.buf[(r.head+r.size)&r.mask] = v
+	r.size++
+}
+
+// PushFront 插入队首，固定容量模式下写满时覆盖队尾
+func (r *Ring[T]) PushFront(v T) {
+	if r.size == len(r.buf) {
+		if r.fixed {
+			r.head = (r.head - 1) & r.mask
+			r.buf[r.head] = v
+			return
+		}
+		r.grow()
+	}
+	r.head = (r.head - 1) & r.mask
+	r.buf[r.head] = v
+	r.size++
+}
+
+// PopFront 删除并返回队首元素，队列为空时返回false
+func (r *Ring[T]) PopFront() (v T, ok bool) {
+	if r.size == 0 {
+		return v, false
+	}
+	var zero T
+	v, r.buf[r.head] = r.buf[r.head], zero // 清掉引用，避免内存泄漏
+	r.head = (r.head + 1) & r.mask
+	r.size--
+	return v, true
+}
+
+// PopBack 删除并返回队尾元素，队列为空时返回false
+func (r *Ring[T]) PopBack() (v T, ok bool) {
+	if r.size == 0 {
+		return v, false
+	}
+	var zero T
+	i := (r.head + r.size - 1) & r.mask
+	v, r.buf[i] = r.buf[i], zero
+	r.size--
+	return v, true
+}
+
+// Front 队首元素，队列为空时返回false
+func (r *Ring[T]) Front() (v T, ok bool) {
+	if r.size == 0 {
+		return v, false
+	}
+	return r.buf[r.head], true
+}
+
+// Back 队尾元素，队列为空时返回false
+func (r *Ring[T]) Back() (v T, ok bool) {
+	if r.size == 0 {
+		return v, false
+	}
+	return r.buf[(r.head+r.size-1)&r.mask], true
+}
+
+// At 从队首开始的第i个元素，越界时panic
+func (r *Ring[T]) At(i int) T {
+	if i < 0 || i >= r.size {
+		panic("array: ring index out of range")
+	}
+	return r.buf[(r.head+i)&r.mask]
+}
+
+// Set 修改从队首开始的第i个元素，越界时panic
+func (r *Ring[T]) Set(i int, v T) {
+	if i < 0 || i >= r.size {
+		panic("array: ring index out of range")
+	}
+	r.buf[(r.head+i)&r.mask] = v
+}
+
+// All 从队首到队尾遍历下标和元素，遍历过程中不能修改队列
+func (r *Ring[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := 0; i < r.size; i++ {
+			if !yield(i, r.buf[(r.head+i)&r.mask]) {
+				return
+			}
+		}
+	}
+}
+
+// Clear 删除所有元素，保留容量
+func (r *Ring[T]) Clear() {
+	clear(r.buf)
+	r.head = 0
+	r.size = 0
+}
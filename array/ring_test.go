@@ -0,0 +1,87 @@
+package array
+
+import (
+	"slices"
+	"testing"
+)
+
+func ringValues[T any](r *Ring[T]) []T {
+	var vs []T
+	for _, v := range r.All() {
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+func TestRingDeque(t *testing.T) {
+	r := NewRing[int](3)
+	if r.Cap() != 4 {
+		t.Fatalf("cap expect 4, got %d", r.Cap())
+	}
+	for i := 1; i <= 5; i++ {
+		r.PushBack(i)
+	}
+	r.PushFront(0)
+	r.PushFront(-1)
+	if r.Cap() != 8 || !slices.Equal(ringValues(r), []int{-1, 0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("got cap %d %v", r.Cap(), ringValues(r))
+	}
+	if r.At(2) != 1 || r.At(r.Len()-1) != 5 {
+		t.Errorf("at got %d %d", r.At(2), r.At(r.Len()-1))
+	}
+	r.Set(0, -2)
+	if v, _ := r.Front(); v != -2 {
+		t.Errorf("front expect -2, got %d", v)
+	}
+	if v, ok := r.PopBack(); v != 5 || !ok {
+		t.Errorf("pop back got %d %v", v, ok)
+	}
+	if v, ok := r.PopFront(); v != -2 || !ok {
+		t.Errorf("pop front got %d %v", v, ok)
+	}
+	if v, _ := r.Back(); v != 4 || r.Len() != 5 {
+		t.Errorf("back got %d len %d", v, r.Len())
+	}
+	r.Clear()
+	if _, ok := r.PopFront(); ok || r.Len() != 0 {
+		t.Errorf("pop empty expect false")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("at out of range expect panic")
+		}
+	}()
+	r.At(0)
+}
+
+func TestRingFixed(t *testing.T) {
+	r := NewFixedRing[string](4)
+	for _, s := range []string{"a", "b", "c", "d", "e", "f"} {
+		r.PushBack(s)
+	}
+	if r.Cap() != 4 || !slices.Equal(ringValues(r), []string{"c", "d", "e", "f"}) {
+		t.Fatalf("push back overwrite got %v", ringValues(r))
+	}
+	r.PushFront("z")
+	if !slices.Equal(ringValues(r), []string{"z", "c", "d", "e"}) {
+		t.Fatalf("push front overwrite got %v", ringValues(r))
+	}
+	for i := 0; i < 10; i++ {
+		r.PopFront()
+		r.PushBack("x")
+	}
+	if r.Len() != 4 || r.Cap() != 4 {
+		t.Errorf("len %d cap %d", r.Len(), r.Cap())
+	}
+}
+
+func BenchmarkRing(b *testing.B) {
+	r := NewRing[int](1024)
+	for i := 0; i < b.N; i++ {
+		r.PushBack(i)
+		if r.Len() > 512 {
+			r.PopFront()
+		}
+	}
+}
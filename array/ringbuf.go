@@ -5,6 +5,7 @@
 package array
 
 import (
+	"bytes"
 	"errors"
 	"io"
 )
@@ -13,11 +14,15 @@ var ErrOutOfRange = errors.New("out of range")
 
 
 // ring buffer底层是一个数组，使用单调递增的左右下标来标识虚拟的存储空间，写到数组末尾之后再从头部开始写
+// 也可以作为FIFO字节管道使用：Write从尾部写入，Read从头部消费并推进begin
 type RingBuf struct {
 	begin int64 // 虚拟存储空间左边界
 	end   int64 // 虚拟存储空间右边界
 	data  []byte
 	index int  // 数组可写下标，另外一种常见的优化是控制数组长度为2的n次方，然后用位操作代替取模
+	shortWrite bool // 写满时的行为，false覆盖最旧的数据，true只写入剩余空间并返回io.ErrShortWrite
+	reserving  bool // 有没有未提交的Reserve，写入、Skip、Resize、Reset之后作废
+	reserved   int  // Reserve预留的长度，Commit不能超过
 }
 
 func NewRingBuf(size int) (rb RingBuf) {
@@ -40,8 +45,28 @@ func (rb *RingBuf) End() int64 {
 	return rb.end
 }
 
+// pos 虚拟偏移量在数组中的下标，end对应可写下标index，往前倒推即可，offset需要在[begin, end]之间
+func (rb *RingBuf) pos(offset int64) int {
+	p := rb.index - int(rb.end-offset)
+	if p < 0 {
+		p += len(rb.data)
+	}
+	return p
+}
+
+// SetShortWrite 设置写满时的行为，默认覆盖最旧的数据，开启后不覆盖未读数据，适合作为网络收发缓冲
+func (rb *RingBuf) SetShortWrite(on bool) {
+	rb.shortWrite = on
+}
+
 // 从尾部写入，写满数组后覆盖写头部，达到ring效果
+// shortWrite模式下只写入剩余空间能放下的部分，写不完时返回io.ErrShortWrite
 func (rb *RingBuf) Write(p []byte) (n int, err error) {
+	rb.reserving = false
+	if rb.shortWrite && len(p) > rb.Free() {
+		p = p[:rb.Free()]
+		err = io.ErrShortWrite
+	}
 	if len(p) > len(rb.data) {
 		err = ErrOutOfRange
 		return
@@ -67,15 +92,7 @@ func (rb *RingBuf) WriteAt(p []byte, offset int64) (n int, err error) {
 		err = ErrOutOfRange
 		return
 	}
-	var writeOff int
-	if rb.end-rb.begin < int64(len(rb.data)) {
-		writeOff = int(offset - rb.begin)
-	} else {
-		writeOff = rb.index + int(offset-rb.begin)
-	}
-	if writeOff > len(rb.data) {
-		writeOff -= len(rb.data)
-	}
+	writeOff := rb.pos(offset)
 	writeEnd := writeOff + int(rb.end-offset)
 	if writeEnd <= len(rb.data) {
 		n = copy(rb.data[writeOff:writeEnd], p)
@@ -93,15 +110,7 @@ func (rb *RingBuf) ReadAt(p []byte, offset int64) (n int, err error) {
 		err = ErrOutOfRange
 		return
 	}
-	var readOff int
-	if rb.end-rb.begin < int64(len(rb.data)) {
-		readOff = int(offset - rb.begin)
-	} else {
-		readOff = rb.index + int(offset-rb.begin)
-	}
-	if readOff >= len(rb.data) {
-		readOff -= len(rb.data)
-	}
+	readOff := rb.pos(offset)
 	readEnd := readOff + int(rb.end-offset)
 	if readEnd <= len(rb.data) {
 		n = copy(p, rb.data[readOff:readEnd])
@@ -121,15 +130,8 @@ func (rb *RingBuf) Evacuate(offset int64, length int) (newOff int64) {
 	if offset+int64(length) > rb.end || offset < rb.begin {
 		return -1
 	}
-	var readOff int
-	if rb.end-rb.begin < int64(len(rb.data)) {
-		readOff = int(offset - rb.begin)
-	} else {
-		readOff = rb.index + int(offset-rb.begin)
-	}
-	if readOff >= len(rb.data) {
-		readOff -= len(rb.data)
-	}
+	rb.reserving = false
+	readOff := rb.pos(offset)
 
 	if readOff == rb.index {
 		// no copy evacuate
@@ -172,11 +174,16 @@ func (rb *RingBuf) Resize(newSize int) {
 	if len(rb.data) == newSize {
 		return
 	}
-	newData := make([]byte, newSize)
-	var offset int
-	if rb.end-rb.begin == int64(len(rb.data)) {
-		offset = rb.index
+	rb.reserving = false
+	if newSize <= 0 {
+		// 没有空间保存任何数据，全部丢弃
+		rb.data = []byte{}
+		rb.begin = rb.end
+		rb.index = 0
+		return
 	}
+	newData := make([]byte, newSize)
+	offset := rb.pos(rb.begin)
 	if int(rb.end-rb.begin) > newSize {
 		discard := int(rb.end-rb.begin) - newSize
 		offset = (offset + discard) % len(rb.data)
@@ -187,10 +194,18 @@ func (rb *RingBuf) Resize(newSize int) {
 		copy(newData[n:], rb.data[:offset])
 	}
 	rb.data = newData
-	rb.index = 0
+	// 数据从新数组头部开始连续存放，可写下标紧跟在数据之后
+	rb.index = int(rb.end-rb.begin) % newSize
 }
 
 func (rb *RingBuf) Skip(length int64) {
+	rb.reserving = false
+	if len(rb.data) == 0 {
+		// Resize(0)之后没有空间保存数据，只推进下标
+		rb.end += length
+		rb.begin = rb.end
+		return
+	}
 	rb.end += length
 	rb.index += int(length)
 	for rb.index >= len(rb.data) {
@@ -199,4 +214,197 @@ func (rb *RingBuf) Skip(length int64) {
 	if int(rb.end-rb.begin) > len(rb.data) {
 		rb.begin = rb.end - int64(len(rb.data))
 	}
-}
\ No newline at end of file
+}
+
+// EqualAt 比较指定位置的数据是否与p相同，不需要拷贝出来
+func (rb *RingBuf) EqualAt(p []byte, offset int64) bool {
+	if offset+int64(len(p)) > rb.end || offset < rb.begin {
+		return false
+	}
+	readOff := rb.pos(offset)
+	readEnd := readOff + len(p)
+	if readEnd <= len(rb.data) {
+		return bytes.Equal(p, rb.data[readOff:readEnd])
+	}
+	firstLen := len(rb.data) - readOff
+	return bytes.Equal(p[:firstLen], rb.data[readOff:]) && bytes.Equal(p[firstLen:], rb.data[:readEnd-len(rb.data)])
+}
+
+// ErrFull shortWrite模式下缓冲区已满，ReadFrom无法继续读入
+var ErrFull = errors.New("ring buffer is full")
+
+// ErrNotReserved Commit的长度超过了Reserve预留的长度，或者没有调用Reserve
+var ErrNotReserved = errors.New("ring buffer: commit exceeds reservation")
+
+// ErrNegativeCount Peek和Discard的参数为负数
+var ErrNegativeCount = errors.New("ring buffer: negative count")
+
+// Len 未读数据的长度
+func (rb *RingBuf) Len() int {
+	return int(rb.end - rb.begin)
+}
+
+// Free 不覆盖未读数据时还能写入的长度
+func (rb *RingBuf) Free() int {
+	return len(rb.data) - rb.Len()
+}
+
+// Reset 清空缓冲区，虚拟下标从0重新开始
+func (rb *RingBuf) Reset() {
+	rb.reserving = false
+	rb.begin = 0
+	rb.end = 0
+	rb.index = 0
+}
+
+// readable 从begin开始的一段连续未读数据，数据绕回数组头部时只返回前半段
+func (rb *RingBuf) readable() []byte {
+	start := rb.pos(rb.begin)
+	return rb.data[start:min(start+rb.Len(), len(rb.data))]
+}
+
+// writable 从index开始的一段连续可写空间，shortWrite模式下不包含未读数据
+func (rb *RingBuf) writable() []byte {
+	if rb.shortWrite {
+		return rb.data[rb.index:min(rb.index+rb.Free(), len(rb.data))]
+	}
+	return rb.data[rb.index:]
+}
+
+// Read 从头部读出并消费数据，没有数据时返回io.EOF
+func (rb *RingBuf) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if rb.Len() == 0 {
+		return 0, io.EOF
+	}
+	n, _ = rb.ReadAt(p, rb.begin)
+	rb.begin += int64(n)
+	return n, nil
+}
+
+// Peek 返回接下来的n个字节的拷贝，不消费数据，数据不足n个时返回全部未读数据和io.EOF
+func (rb *RingBuf) Peek(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, ErrNegativeCount
+	}
+	var err error
+	if n > rb.Len() {
+		n = rb.Len()
+		err = io.EOF
+	}
+	p := make([]byte, n)
+	rb.ReadAt(p, rb.begin)
+	return p, err
+}
+
+// Discard 跳过接下来的n个字节，数据不足n个时跳过全部未读数据并返回io.EOF
+func (rb *RingBuf) Discard(n int) (discarded int, err error) {
+	if n < 0 {
+		return 0, ErrNegativeCount
+	}
+	if n > rb.Len() {
+		n = rb.Len()
+		err = io.EOF
+	}
+	rb.begin += int64(n)
+	return n, err
+}
+
+// ReadFrom 实现io.ReaderFrom，从r读入数据直到io.EOF，直接读到底层数组中，不需要中间缓冲
+// shortWrite模式下缓冲区写满时返回ErrFull，覆盖模式下会一直覆盖最旧的数据
+func (rb *RingBuf) ReadFrom(r io.Reader) (n int64, err error) {
+	if len(rb.data) == 0 {
+		return 0, ErrFull
+	}
+	for {
+		buf := rb.writable()
+		if len(buf) == 0 {
+			return n, ErrFull
+		}
+		m, err := r.Read(buf)
+		if m < 0 || m > len(buf) {
+			panic("array: reader returned invalid count")
+		}
+		rb.Skip(int64(m))
+		n += int64(m)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// WriteTo 实现io.WriterTo，把未读数据全部写入w并消费掉
+func (rb *RingBuf) WriteTo(w io.Writer) (n int64, err error) {
+	for rb.Len() > 0 {
+		buf := rb.readable()
+		m, err := w.Write(buf)
+		if m < 0 || m > len(buf) {
+			panic("array: writer returned invalid count")
+		}
+		rb.begin += int64(m)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+		if m < len(buf) {
+			return n, io.ErrShortWrite
+		}
+	}
+	return n, nil
+}
+
+// split 从数组下标start开始取n个字节，在数组末尾处切成两段
+func (rb *RingBuf) split(start, n int) (first, second []byte) {
+	if start+n <= len(rb.data) {
+		return rb.data[start : start+n], nil
+	}
+	return rb.data[start:], rb.data[:start+n-len(rb.data)]
+}
+
+// Slices 返回[offset, offset+n)在底层数组中的视图，数据绕回数组头部时分成两段，second为nil表示数据是连续的
+// 不拷贝数据，可以直接交给writev之类的接口，视图在下一次写入之前有效
+func (rb *RingBuf) Slices(offset int64, n int) (first, second []byte, err error) {
+	if n < 0 || offset < rb.begin || offset+int64(n) > rb.end {
+		return nil, nil, ErrOutOfRange
+	}
+	first, second = rb.split(rb.pos(offset), n)
+	return first, second, nil
+}
+
+// Reserve 预留尾部n个字节的可写空间，返回底层数组中的视图，直接写入后调用Commit提交，不需要中间缓冲
+// 覆盖模式下预留的空间可能包含最旧的数据，Commit之后才推进begin；shortWrite模式下空间不足时返回ErrFull
+func (rb *RingBuf) Reserve(n int) (first, second []byte, err error) {
+	if len(rb.data) == 0 {
+		return nil, nil, ErrFull
+	}
+	if n < 0 || n > len(rb.data) {
+		return nil, nil, ErrOutOfRange
+	}
+	if rb.shortWrite && n > rb.Free() {
+		return nil, nil, ErrFull
+	}
+	first, second = rb.split(rb.index, n)
+	rb.reserving, rb.reserved = true, n
+	return first, second, nil
+}
+
+// Commit 提交Reserve之后写入的前n个字节，推进end，每次Reserve只能提交一次
+// n超过预留的长度、没有Reserve或者Reserve之后有其他写入时返回ErrNotReserved，避免把没有写过的旧数据发布出去
+func (rb *RingBuf) Commit(n int) error {
+	if n < 0 {
+		return ErrNegativeCount
+	}
+	if len(rb.data) == 0 {
+		return ErrFull
+	}
+	if !rb.reserving || n > rb.reserved {
+		return ErrNotReserved
+	}
+	rb.Skip(int64(n))
+	return nil
+}
@@ -0,0 +1,237 @@
+package array
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRingBufFIFO(t *testing.T) {
+	rb := NewRingBuf(8)
+	rb.SetShortWrite(true)
+	if n, err := rb.Write([]byte("abcdef")); n != 6 || err != nil {
+		t.Fatalf("write expect 6, nil, got %d, %v", n, err)
+	}
+	p := make([]byte, 4)
+	if n, _ := rb.Read(p); n != 4 || string(p) != "abcd" {
+		t.Fatalf("read expect abcd, got %q", p[:n])
+	}
+	// 写入绕回数组头部，只能写入剩余空间
+	if n, err := rb.Write([]byte("ghijklmn")); n != 6 || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("write expect 6, ErrShortWrite, got %d, %v", n, err)
+	}
+	if rb.Len() != 8 || rb.Free() != 0 {
+		t.Fatalf("len %d free %d", rb.Len(), rb.Free())
+	}
+	if b, err := rb.Peek(3); string(b) != "efg" || err != nil {
+		t.Errorf("peek expect efg, got %q, %v", b, err)
+	}
+	if n, err := rb.Discard(1); n != 1 || err != nil {
+		t.Errorf("discard expect 1, got %d, %v", n, err)
+	}
+	// ReadAt使用的虚拟偏移量不受消费影响
+	p = make([]byte, 3)
+	if n, _ := rb.ReadAt(p, rb.Begin()+2); n != 3 || string(p) != "hij" {
+		t.Errorf("read at expect hij, got %q", p[:n])
+	}
+	var sb strings.Builder
+	if n, err := rb.WriteTo(&sb); n != 7 || err != nil || sb.String() != "fghijkl" {
+		t.Errorf("write to expect fghijkl, got %q, %d, %v", sb.String(), n, err)
+	}
+	if n, err := rb.Read(p); n != 0 || err != io.EOF {
+		t.Errorf("read empty expect EOF, got %d, %v", n, err)
+	}
+	if b, err := rb.Peek(1); len(b) != 0 || err != io.EOF {
+		t.Errorf("peek empty expect EOF, got %q, %v", b, err)
+	}
+	rb.Reset()
+	if rb.Len() != 0 || rb.Begin() != 0 || rb.End() != 0 {
+		t.Errorf("reset failed")
+	}
+}
+
+func TestRingBufOverwrite(t *testing.T) {
+	rb := NewRingBuf(4)
+	rb.Write([]byte("abc"))
+	p := make([]byte, 2)
+	rb.Read(p)
+	// 默认覆盖最旧的数据
+	if n, err := rb.Write([]byte("defg")); n != 4 || err != nil {
+		t.Fatalf("write expect 4, nil, got %d, %v", n, err)
+	}
+	if b, _ := rb.Peek(4); string(b) != "defg" {
+		t.Errorf("peek expect defg, got %q", b)
+	}
+	n, err := rb.ReadFrom(strings.NewReader("hijkl"))
+	if n != 5 || err != nil {
+		t.Errorf("read from expect 5, nil, got %d, %v", n, err)
+	}
+	if b, _ := rb.Peek(4); string(b) != "ijkl" {
+		t.Errorf("peek expect ijkl, got %q", b)
+	}
+}
+
+func TestRingBufReadFrom(t *testing.T) {
+	rb := NewRingBuf(16)
+	rb.SetShortWrite(true)
+	rb.Write([]byte("0123456789"))
+	rb.Discard(8)
+	// 一次读1个字节，跨越数组末尾
+	n, err := rb.ReadFrom(iotest.OneByteReader(strings.NewReader("abcdefghij")))
+	if n != 10 || err != nil {
+		t.Fatalf("read from expect 10, nil, got %d, %v", n, err)
+	}
+	n, err = rb.ReadFrom(strings.NewReader("klmnopq"))
+	if n != 4 || !errors.Is(err, ErrFull) {
+		t.Errorf("read from full expect 4, ErrFull, got %d, %v", n, err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, &rb); err != nil || buf.String() != "89abcdefghijklmn" {
+		t.Errorf("copy expect 89abcdefghijklmn, got %q, %v", buf.String(), err)
+	}
+}
+
+func TestRingBufResize(t *testing.T) {
+	rb := NewRingBuf(8)
+	rb.SetShortWrite(true)
+	rb.Write([]byte("abcdef"))
+	rb.Discard(4)
+	rb.Write([]byte("ghij"))
+	rb.Resize(16)
+	// 扩容后继续写入不能覆盖已有数据
+	rb.Write([]byte("klm"))
+	if b, _ := rb.Peek(rb.Len()); string(b) != "efghijklm" {
+		t.Errorf("peek expect efghijklm, got %q", b)
+	}
+	rb.Resize(4)
+	if b, _ := rb.Peek(rb.Len()); string(b) != "jklm" {
+		t.Errorf("peek expect jklm, got %q", b)
+	}
+	// 缩容到0丢弃全部数据，之后还能扩容继续使用
+	end := rb.End()
+	rb.Resize(0)
+	if rb.Size() != 0 || rb.Len() != 0 || rb.Begin() != end || rb.End() != end {
+		t.Errorf("resize 0 got size %d [%d, %d)", rb.Size(), rb.Begin(), rb.End())
+	}
+	rb.Resize(4)
+	rb.Write([]byte("no"))
+	if b, _ := rb.Peek(rb.Len()); string(b) != "no" || rb.Begin() != end {
+		t.Errorf("peek after regrow expect no, got %q from %d", b, rb.Begin())
+	}
+}
+
+func TestRingBufZeroSize(t *testing.T) {
+	rb := NewRingBuf(4)
+	rb.Write([]byte("abc"))
+	rb.Reserve(1)
+	rb.Resize(0)
+	// 缩容到0之后Reserve、Commit、Skip都不能卡住
+	if _, _, err := rb.Reserve(0); !errors.Is(err, ErrFull) {
+		t.Errorf("reserve on empty buffer expect ErrFull, got %v", err)
+	}
+	if err := rb.Commit(0); !errors.Is(err, ErrFull) {
+		t.Errorf("commit on empty buffer expect ErrFull, got %v", err)
+	}
+	rb.Skip(5)
+	if rb.End() != 8 || rb.Begin() != 8 || rb.Len() != 0 {
+		t.Errorf("skip on empty buffer got [%d, %d)", rb.Begin(), rb.End())
+	}
+}
+
+func TestRingBufNegativeCount(t *testing.T) {
+	rb := NewRingBuf(8)
+	rb.Write([]byte("abcdef"))
+	rb.Discard(4)
+	if b, err := rb.Peek(-1); b != nil || !errors.Is(err, ErrNegativeCount) {
+		t.Errorf("peek -1 expect ErrNegativeCount, got %q, %v", b, err)
+	}
+	if n, err := rb.Discard(-5); n != 0 || !errors.Is(err, ErrNegativeCount) {
+		t.Errorf("discard -5 expect ErrNegativeCount, got %d, %v", n, err)
+	}
+	if rb.Begin() != 4 || rb.Len() != 2 {
+		t.Errorf("negative count should not move begin, got [%d, %d)", rb.Begin(), rb.End())
+	}
+}
+
+func TestRingBufSlices(t *testing.T) {
+	rb := NewRingBuf(8)
+	rb.SetShortWrite(true)
+	rb.Write([]byte("abcdef"))
+	rb.Discard(4)
+	rb.Write([]byte("ghij"))
+	first, second, err := rb.Slices(rb.Begin(), rb.Len())
+	if string(first) != "efgh" || string(second) != "ij" || err != nil {
+		t.Fatalf("slices got %q %q %v", first, second, err)
+	}
+	first, second, _ = rb.Slices(rb.Begin()+1, 2)
+	if string(first) != "fg" || second != nil {
+		t.Errorf("contiguous slices got %q %q", first, second)
+	}
+	if _, _, err := rb.Slices(rb.Begin(), rb.Len()+1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("slices past end expect ErrOutOfRange, got %v", err)
+	}
+
+	rb = NewRingBuf(8)
+	rb.SetShortWrite(true)
+	rb.Write([]byte("abcdef"))
+	rb.Discard(6)
+	first, second, err = rb.Reserve(4)
+	if len(first) != 2 || len(second) != 2 || err != nil {
+		t.Fatalf("reserve got %d %d %v", len(first), len(second), err)
+	}
+	copy(first, "kl")
+	copy(second, "mn")
+	if err := rb.Commit(4); err != nil {
+		t.Fatal(err)
+	}
+	rb.Write([]byte("op"))
+	if b, _ := rb.Peek(rb.Len()); string(b) != "klmnop" {
+		t.Errorf("after commit got %q", b)
+	}
+	if _, _, err := rb.Reserve(3); !errors.Is(err, ErrFull) {
+		t.Errorf("reserve over free expect ErrFull, got %v", err)
+	}
+
+	// 覆盖模式下Commit推进begin
+	rb.SetShortWrite(false)
+	first, second, _ = rb.Reserve(4)
+	copy(first, "qrst")
+	rb.Commit(4)
+	if b, _ := rb.Peek(rb.Len()); string(b) != "mnopqrst" || second != nil {
+		t.Errorf("overwrite commit got %q", b)
+	}
+}
+
+func TestRingBufOverCommit(t *testing.T) {
+	rb := NewRingBuf(8)
+	if err := rb.Commit(1); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("commit without reserve expect ErrNotReserved, got %v", err)
+	}
+	first, _, _ := rb.Reserve(2)
+	copy(first, "ab")
+	if err := rb.Commit(3); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("commit over reservation expect ErrNotReserved, got %v", err)
+	}
+	if err := rb.Commit(-1); !errors.Is(err, ErrNegativeCount) {
+		t.Errorf("commit -1 expect ErrNegativeCount, got %v", err)
+	}
+	// 只提交一部分，剩下的预留作废
+	if err := rb.Commit(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := rb.Commit(1); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("second commit expect ErrNotReserved, got %v", err)
+	}
+	// Reserve之后的写入让预留作废
+	rb.Reserve(4)
+	rb.Write([]byte("x"))
+	if err := rb.Commit(1); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("commit after write expect ErrNotReserved, got %v", err)
+	}
+	if b, _ := rb.Peek(rb.Len()); string(b) != "ax" {
+		t.Errorf("expect ax, got %q", b)
+	}
+}
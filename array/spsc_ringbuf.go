@@ -0,0 +1,131 @@
+package array
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+// SPSCRingBuf 单生产者单消费者的无锁阻塞ring buffer
+// 原理：读下标head只由消费者修改，写下标tail只由生产者修改，双方通过原子读写对方的下标计算可读数据和剩余空间，不需要加锁
+// 数组长度取2的n次方，用位运算代替取模
+// 一方需要等待时阻塞在容量为1的信号通道上，另一方推进下标后非阻塞地发送一个信号
+// 信号通道已满时发送不需要加锁，所以没有等待者时的快速路径上没有锁；多余的信号只会导致一次多余的检查
+// 只能有一个goroutine读、一个goroutine写，多读多写使用SyncRingBuf
+type SPSCRingBuf struct {
+	data     []byte
+	mask     int64
+	head     atomic.Int64 // 读下标，只有消费者修改
+	tail     atomic.Int64 // 写下标，只有生产者修改
+	closed   atomic.Bool
+	readable chan struct{} // 有新数据或者CloseWrite
+	writable chan struct{} // 有新空间
+}
+
+// NewSPSCRingBuf constructor，size向上取整到2的n次方
+func NewSPSCRingBuf(size int) *SPSCRingBuf {
+	n := roundPow2(size)
+	return &SPSCRingBuf{
+		data:     make([]byte, n),
+		mask:     int64(n - 1),
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+	}
+}
+
+// signal 非阻塞地发送信号，已经有信号时直接返回
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Read 读出数据，没有数据时阻塞，CloseWrite之后读完剩余数据返回io.EOF，只能由消费者调用
+func (b *SPSCRingBuf) Read(p []byte) (int, error) {
+	return b.ReadContext(context.Background(), p)
+}
+
+// ReadContext 读出数据，没有数据时阻塞直到有数据、CloseWrite或者ctx结束
+func (b *SPSCRingBuf) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	head := b.head.Load()
+	for {
+		tail := b.tail.Load()
+		if tail > head {
+			n := int(min(int64(len(p)), tail-head))
+			start := int(head & b.mask)
+			m := copy(p[:n], b.data[start:])
+			copy(p[m:n], b.data)
+			b.head.Store(head + int64(n))
+			signal(b.writable)
+			return n, nil
+		}
+		if b.closed.Load() {
+			// closed之前的最后一次写入可能还没看到，再检查一次
+			if b.tail.Load() == head {
+				return 0, io.EOF
+			}
+			continue
+		}
+		select {
+		case <-b.readable:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// Write 写入全部数据，空间不足时阻塞，CloseWrite之后返回io.ErrClosedPipe，只能由生产者调用
+func (b *SPSCRingBuf) Write(p []byte) (int, error) {
+	return b.WriteContext(context.Background(), p)
+}
+
+// WriteContext 写入全部数据，空间不足时阻塞直到有空间或者ctx结束，返回已写入的长度
+func (b *SPSCRingBuf) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	tail := b.tail.Load()
+	for n < len(p) {
+		if b.closed.Load() {
+			return n, io.ErrClosedPipe
+		}
+		free := int64(len(b.data)) - (tail - b.head.Load())
+		if free == 0 {
+			select {
+			case <-b.writable:
+			case <-ctx.Done():
+				return n, ctx.Err()
+			}
+			continue
+		}
+		m := int(min(free, int64(len(p)-n)))
+		start := int(tail & b.mask)
+		c := copy(b.data[start:], p[n:n+m])
+		copy(b.data, p[n+c:n+m])
+		tail += int64(m)
+		b.tail.Store(tail)
+		n += m
+		signal(b.readable)
+	}
+	return n, nil
+}
+
+// CloseWrite 结束写入，读者读完剩余数据后得到io.EOF，只能由生产者调用
+func (b *SPSCRingBuf) CloseWrite() error {
+	b.closed.Store(true)
+	signal(b.readable)
+	return nil
+}
+
+// Len 未读数据的长度，并发读写时只是一个近似值
+// 先读head再读tail，两次读取之间tail只会增大，结果不会是负数；但可能加上了期间新写入的数据，所以不超过数组长度
+func (b *SPSCRingBuf) Len() int {
+	head := b.head.Load()
+	return int(min(b.tail.Load()-head, int64(len(b.data))))
+}
+
+// Free 剩余可写空间，并发读写时只是一个近似值
+func (b *SPSCRingBuf) Free() int {
+	return len(b.data) - b.Len()
+}
@@ -0,0 +1,114 @@
+package array
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSPSCRingBufWrap(t *testing.T) {
+	// 5不是2的n次方，向上取整到8
+	b := NewSPSCRingBuf(5)
+	if b.Free() != 8 {
+		t.Fatalf("free expect 8, got %d", b.Free())
+	}
+	p := make([]byte, 8)
+	b.Write([]byte("abcdef"))
+	if n, _ := b.Read(p[:4]); string(p[:n]) != "abcd" {
+		t.Fatalf("read expect abcd, got %q", p[:n])
+	}
+	// 写入跨过数组末尾，分成data[6:8]和data[0:4]两段
+	b.Write([]byte("ghijkl"))
+	if b.Len() != 8 || b.Free() != 0 {
+		t.Fatalf("len %d free %d", b.Len(), b.Free())
+	}
+	if n, _ := b.Read(p); string(p[:n]) != "efghijkl" {
+		t.Errorf("wrapped read expect efghijkl, got %q", p[:n])
+	}
+	if b.Len() != 0 || b.Free() != 8 {
+		t.Errorf("after drain len %d free %d", b.Len(), b.Free())
+	}
+}
+
+func TestSPSCRingBufPartial(t *testing.T) {
+	b := NewSPSCRingBuf(8)
+	// 读缓冲比数据短时按顺序分多次读出，跨过数组末尾也不乱序
+	var got []byte
+	p := make([]byte, 3)
+	for round := 0; round < 4; round++ {
+		b.Write([]byte("012345"))
+		for b.Len() > 0 {
+			n, err := b.Read(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, p[:n]...)
+		}
+	}
+	if want := bytes.Repeat([]byte("012345"), 4); !bytes.Equal(got, want) {
+		t.Errorf("partial reads expect %q, got %q", want, got)
+	}
+
+	// 写入超过剩余空间时阻塞，读出一部分之后继续写完剩余数据
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if n, err := b.Write([]byte("abcdefghijkl")); n != 12 || err != nil {
+			t.Errorf("blocking write got %d, %v", n, err)
+		}
+		b.CloseWrite()
+	}()
+	all, err := io.ReadAll(b)
+	<-done
+	if string(all) != "abcdefghijkl" || err != nil {
+		t.Errorf("read after partial writes got %q, %v", all, err)
+	}
+}
+
+func TestSPSCRingBufLenConcurrent(t *testing.T) {
+	b := NewSPSCRingBuf(16)
+	const total = 1 << 16
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		// 读写双方都在运行时，Len和Free始终在[0, size]之间
+		defer wg.Done()
+		for !stop.Load() {
+			if n := b.Len(); n < 0 || n > 16 {
+				t.Errorf("len out of range: %d", n)
+				return
+			}
+			if n := b.Free(); n < 0 || n > 16 {
+				t.Errorf("free out of range: %d", n)
+				return
+			}
+		}
+	}()
+	go func() {
+		src := make([]byte, 7)
+		for i := 0; i < total; i += len(src) {
+			for j := range src {
+				src[j] = byte(i + j)
+			}
+			b.Write(src[:min(len(src), total-i)])
+		}
+		b.CloseWrite()
+	}()
+	got, err := io.ReadAll(b)
+	stop.Store(true)
+	wg.Wait()
+	if err != nil || len(got) != total {
+		t.Fatalf("read %d bytes, %v", len(got), err)
+	}
+	for i, c := range got {
+		if c != byte(i) {
+			t.Fatalf("byte %d expect %d, got %d", i, byte(i), c)
+		}
+	}
+	if b.Len() != 0 {
+		t.Errorf("len after drain expect 0, got %d", b.Len())
+	}
+}
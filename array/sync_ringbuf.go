@@ -0,0 +1,126 @@
+package array
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// SyncRingBuf 并发安全的阻塞ring buffer，可以作为进程内的字节管道
+// 原理：RingBuf开启shortWrite模式，所有操作加互斥锁
+// 读在没有数据时等待，写在没有空间时等待，等待者拿到当前的changed通道后释放锁，状态变化时关闭changed唤醒所有等待者
+// 用通道而不是sync.Cond等待，是为了能同时监听ctx.Done()
+// 只有一个读者和一个写者时可以使用无锁的SPSCRingBuf
+type SyncRingBuf struct {
+	mu      sync.Mutex
+	rb      RingBuf
+	closed  bool          // CloseWrite之后不能再写入
+	waiters int           // 正在等待的读者和写者数量，没有等待者时不需要通知
+	changed chan struct{} // 状态变化时关闭并替换
+}
+
+// NewSyncRingBuf constructor
+func NewSyncRingBuf(size int) *SyncRingBuf {
+	b := &SyncRingBuf{rb: NewRingBuf(size), changed: make(chan struct{})}
+	b.rb.SetShortWrite(true)
+	return b
+}
+
+// wait 等待状态变化，调用方持有锁，返回时重新持有锁
+func (b *SyncRingBuf) wait(ctx context.Context) error {
+	ch := b.changed
+	b.waiters++
+	b.mu.Unlock()
+	var err error
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	b.mu.Lock()
+	b.waiters--
+	return err
+}
+
+// notify 唤醒所有等待者，调用方持有锁
+func (b *SyncRingBuf) notify() {
+	if b.waiters == 0 {
+		return
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Read 读出数据，没有数据时阻塞，CloseWrite之后读完剩余数据返回io.EOF
+func (b *SyncRingBuf) Read(p []byte) (int, error) {
+	return b.ReadContext(context.Background(), p)
+}
+
+// ReadContext 读出数据，没有数据时阻塞直到有数据、CloseWrite或者ctx结束
+func (b *SyncRingBuf) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.rb.Len() == 0 {
+		if b.closed {
+			return 0, io.EOF
+		}
+		if err := b.wait(ctx); err != nil {
+			return 0, err
+		}
+	}
+	n, _ := b.rb.Read(p)
+	b.notify()
+	return n, nil
+}
+
+// Write 写入全部数据，空间不足时阻塞，CloseWrite之后返回io.ErrClosedPipe
+func (b *SyncRingBuf) Write(p []byte) (int, error) {
+	return b.WriteContext(context.Background(), p)
+}
+
+// WriteContext 写入全部数据，空间不足时阻塞直到有空间或者ctx结束，返回已写入的长度
+func (b *SyncRingBuf) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for n < len(p) {
+		if b.closed {
+			return n, io.ErrClosedPipe
+		}
+		if b.rb.Free() == 0 {
+			if err := b.wait(ctx); err != nil {
+				return n, err
+			}
+			continue
+		}
+		m, _ := b.rb.Write(p[n:])
+		n += m
+		b.notify()
+	}
+	return n, nil
+}
+
+// CloseWrite 结束写入，读者读完剩余数据后得到io.EOF，阻塞中的写者得到io.ErrClosedPipe
+func (b *SyncRingBuf) CloseWrite() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.notify()
+	return nil
+}
+
+// Len 未读数据的长度
+func (b *SyncRingBuf) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rb.Len()
+}
+
+// Free 剩余可写空间
+func (b *SyncRingBuf) Free() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rb.Free()
+}
@@ -0,0 +1,145 @@
+package array
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingRingBuf SyncRingBuf和SPSCRingBuf共同的方法
+type blockingRingBuf interface {
+	io.Reader
+	io.Writer
+	ReadContext(ctx context.Context, p []byte) (int, error)
+	WriteContext(ctx context.Context, p []byte) (int, error)
+	CloseWrite() error
+	Len() int
+}
+
+var blockingRingBufs = map[string]func(size int) blockingRingBuf{
+	"sync": func(size int) blockingRingBuf { return NewSyncRingBuf(size) },
+	"spsc": func(size int) blockingRingBuf { return NewSPSCRingBuf(size) },
+}
+
+func TestBlockingRingBufStream(t *testing.T) {
+	src := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(src)
+	for name, newBuf := range blockingRingBufs {
+		b := newBuf(64)
+		go func() {
+			// 随机长度写入，经常超过缓冲区长度，需要分多次等待
+			r := rand.New(rand.NewSource(2))
+			for off := 0; off < len(src); {
+				n := min(r.Intn(200), len(src)-off)
+				if _, err := b.Write(src[off : off+n]); err != nil {
+					t.Errorf("%s: write: %v", name, err)
+					return
+				}
+				off += n
+			}
+			b.CloseWrite()
+		}()
+		got, err := io.ReadAll(b)
+		if err != nil || !bytes.Equal(got, src) {
+			t.Errorf("%s: read all got %d bytes, %v", name, len(got), err)
+		}
+		if _, err := b.Write([]byte("x")); !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("%s: write after close expect ErrClosedPipe, got %v", name, err)
+		}
+	}
+}
+
+func TestBlockingRingBufContext(t *testing.T) {
+	for name, newBuf := range blockingRingBufs {
+		b := newBuf(4)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		p := make([]byte, 4)
+		if _, err := b.ReadContext(ctx, p); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: read empty expect DeadlineExceeded, got %v", name, err)
+		}
+		cancel()
+
+		ctx, cancel = context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+		n, err := b.WriteContext(ctx, []byte("abcdef"))
+		if n != 4 || !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: write full expect 4, Canceled, got %d, %v", name, n, err)
+		}
+		// 取消不影响已经写入的数据
+		b.CloseWrite()
+		got, err := io.ReadAll(b)
+		if string(got) != "abcd" || err != nil {
+			t.Errorf("%s: read expect abcd, got %q, %v", name, got, err)
+		}
+	}
+}
+
+func TestSyncRingBufConcurrent(t *testing.T) {
+	b := NewSyncRingBuf(16)
+	const writers, perWriter = 4, 10000
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				b.Write([]byte{1})
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		b.CloseWrite()
+	}()
+	var mu sync.Mutex
+	total := 0
+	var rg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		rg.Add(1)
+		go func() {
+			defer rg.Done()
+			p := make([]byte, 7)
+			for {
+				n, err := b.Read(p)
+				mu.Lock()
+				total += n
+				mu.Unlock()
+				if err == io.EOF {
+					return
+				}
+			}
+		}()
+	}
+	rg.Wait()
+	if total != writers*perWriter {
+		t.Errorf("total expect %d, got %d", writers*perWriter, total)
+	}
+}
+
+func BenchmarkSyncRingBuf(b *testing.B) {
+	benchmarkBlockingRingBuf(b, NewSyncRingBuf(4096))
+}
+
+func BenchmarkSPSCRingBuf(b *testing.B) {
+	benchmarkBlockingRingBuf(b, NewSPSCRingBuf(4096))
+}
+
+func benchmarkBlockingRingBuf(b *testing.B, rb blockingRingBuf) {
+	chunk := make([]byte, 64)
+	b.SetBytes(int64(len(chunk)))
+	go func() {
+		for i := 0; i < b.N; i++ {
+			rb.Write(chunk)
+		}
+		rb.CloseWrite()
+	}()
+	io.Copy(io.Discard, rb)
+}
@@ -0,0 +1,182 @@
+package cache
+
+// ARCCache 自适应替换缓存(Adaptive Replacement Cache)
+// 原理：维护4个双向链表，T1保存只访问过一次的key，T2保存访问过至少两次的key，体现新鲜度和频率两个维度
+// B1、B2是幽灵链表，只保存从T1、T2淘汰的key，不保存值
+// 写入时命中B1说明T1太小，调大T1的目标大小p；命中B2说明T2太小，调小p，从而在扫描和热点两种访问模式之间自适应
+// ref: https://www.usenix.org/legacy/events/fast03/tech/full_papers/megiddo/megiddo.pdf
+
+// ARCCache 结构
+type ARCCache[K comparable, V any] struct {
+	metrics[K, V]
+	capacity int
+	p        int // T1的目标大小
+	store    map[K]*entry[K, V]
+	t1       entryList[K, V]
+	t2       entryList[K, V]
+	b1       entryList[K, V]
+	b2       entryList[K, V]
+}
+
+// NewARCCache constructor
+func NewARCCache[K comparable, V any](capacity int) *ARCCache[K, V] {
+	return &ARCCache[K, V]{
+		capacity: capacity,
+		store:    map[K]*entry[K, V]{},
+	}
+}
+
+// resident 判断节点是否在T1/T2中
+func (c *ARCCache[K, V]) resident(node *entry[K, V]) bool {
+	return node.list == &c.t1 || node.list == &c.t2
+}
+
+// removeLRU 删除链表的表尾，T1/T2中的节点触发淘汰回调
+func (c *ARCCache[K, V]) removeLRU(l *entryList[K, V]) {
+	node := l.tail
+	resident := c.resident(node)
+	l.unlink(node)
+	delete(c.store, node.key)
+	if resident {
+		c.evicted(node.key, node.value, EvictCapacity)
+	}
+}
+
+// replace 腾出一个位置，T1超过目标大小时把T1的表尾淘汰到B1，否则把T2的表尾淘汰到B2
+func (c *ARCCache[K, V]) replace(inB2 bool) {
+	var node *entry[K, V]
+	if c.t1.length > 0 && (c.t1.length > c.p || (inB2 && c.t1.length == c.p)) {
+		node = c.t1.tail
+		node.moveTo(&c.b1)
+	} else if c.t2.length > 0 {
+		node = c.t2.tail
+		node.moveTo(&c.b2)
+	} else {
+		return
+	}
+	var zero V
+	value := node.value
+	node.value = zero
+	c.evicted(node.key, value, EvictCapacity)
+}
+
+// Delete 删除key，同时清理幽灵记录
+func (c *ARCCache[K, V]) Delete(key K) {
+	node, exist := c.store[key]
+	if !exist {
+		return
+	}
+	resident := c.resident(node)
+	delete(c.store, key)
+	node.list.unlink(node)
+	if resident {
+		c.evicted(key, node.value, EvictDelete)
+	}
+}
+
+// Get 获取kv，命中时移到T2表头
+func (c *ARCCache[K, V]) Get(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist || !c.resident(node) {
+		c.miss()
+		return
+	}
+	c.hit()
+	node.moveTo(&c.t2)
+	return node.value, true
+}
+
+// Peek 获取kv，不影响淘汰顺序
+func (c *ARCCache[K, V]) Peek(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist || !c.resident(node) {
+		return
+	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不影响淘汰顺序
+func (c *ARCCache[K, V]) Contains(key K) bool {
+	node, exist := c.store[key]
+	return exist && c.resident(node)
+}
+
+// Put 插入
+func (c *ARCCache[K, V]) Put(key K, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.put()
+	node, exist := c.store[key]
+	if exist {
+		switch node.list {
+		case &c.t1, &c.t2:
+			// 已经在缓存中，更新值并移到T2
+			old := node.value
+			node.value = value
+			node.moveTo(&c.t2)
+			c.evicted(key, old, EvictReplace)
+			return
+		case &c.b1:
+			// 命中B1，调大p
+			delta := 1
+			if c.b1.length < c.b2.length {
+				delta = c.b2.length / c.b1.length
+			}
+			c.p = min(c.p+delta, c.capacity)
+		case &c.b2:
+			// 命中B2，调小p
+			delta := 1
+			if c.b2.length < c.b1.length {
+				delta = c.b1.length / c.b2.length
+			}
+			c.p = max(c.p-delta, 0)
+		}
+		inB2 := node.list == &c.b2
+		if c.t1.length+c.t2.length >= c.capacity {
+			c.replace(inB2)
+		}
+		node.value = value
+		node.moveTo(&c.t2)
+		return
+	}
+
+	// 新key
+	if c.t1.length+c.b1.length >= c.capacity {
+		if c.t1.length < c.capacity {
+			c.removeLRU(&c.b1)
+			if c.t1.length+c.t2.length >= c.capacity {
+				c.replace(false)
+			}
+		} else {
+			// B1为空，T1已满，直接淘汰T1表尾，不进入幽灵链表
+			c.removeLRU(&c.t1)
+		}
+	} else if total := c.t1.length + c.t2.length + c.b1.length + c.b2.length; total >= c.capacity {
+		if total >= 2*c.capacity {
+			c.removeLRU(&c.b2)
+		}
+		if c.t1.length+c.t2.length >= c.capacity {
+			c.replace(false)
+		}
+	}
+	node = &entry[K, V]{key: key, value: value}
+	c.t1.pushFront(node)
+	c.store[key] = node
+}
+
+// Len 当前缓存的key数量，不包含幽灵记录
+func (c *ARCCache[K, V]) Len() int {
+	return c.t1.length + c.t2.length
+}
+
+// Keys 返回所有缓存的key，先T1后T2，链表内按新鲜度从新到旧
+func (c *ARCCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	for _, l := range []*entryList[K, V]{&c.t1, &c.t2} {
+		for node := l.head; node != nil; node = node.next {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
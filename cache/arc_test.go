@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestARCScanResistant(t *testing.T) {
+	cache := NewARCCache[int, int](10)
+	// 热点key访问两次进入T2
+	for i := 0; i < 5; i++ {
+		cache.Put(i, i)
+		cache.Get(i)
+	}
+	// 大量只访问一次的扫描key只会在T1中互相淘汰
+	for i := 100; i < 1000; i++ {
+		cache.Put(i, i)
+	}
+	for i := 0; i < 5; i++ {
+		if v, ok := cache.Get(i); !ok || v != i {
+			t.Errorf("hot key %d should survive scan, got %v, %v", i, v, ok)
+		}
+	}
+	if cache.Len() != 10 {
+		t.Errorf("len expect 10, got %d", cache.Len())
+	}
+}
+
+func TestARCAdapt(t *testing.T) {
+	cache := NewARCCache[int, int](4)
+	for i := 0; i < 4; i++ {
+		cache.Put(i, i)
+	}
+	cache.Get(0)
+	cache.Get(1)
+	// T1: 3 2, T2: 1 0，写入4淘汰T1表尾2到B1
+	cache.Put(4, 4)
+	if cache.Contains(2) {
+		t.Errorf("2 should be evicted to B1")
+	}
+	if node := cache.store[2]; node == nil || node.list != &cache.b1 {
+		t.Errorf("2 should be a ghost in B1")
+	}
+	// 命中B1，p变大，2直接进入T2
+	cache.Put(2, 20)
+	if cache.p != 1 {
+		t.Errorf("p expect 1, got %d", cache.p)
+	}
+	if v, ok := cache.Peek(2); !ok || v != 20 || cache.store[2].list != &cache.t2 {
+		t.Errorf("2 should be in T2 with value 20, got %v, %v", v, ok)
+	}
+	if cache.Len() != 4 {
+		t.Errorf("len expect 4, got %d", cache.Len())
+	}
+}
+
+func TestARCBounds(t *testing.T) {
+	capacity := 32
+	cache := NewARCCache[int, int](capacity)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		k := r.Intn(200)
+		switch r.Intn(10) {
+		case 0:
+			cache.Delete(k)
+		case 1, 2, 3, 4:
+			cache.Put(k, k)
+		default:
+			if v, ok := cache.Get(k); ok && v != k {
+				t.Fatalf("get %d got %d", k, v)
+			}
+		}
+		if cache.Len() > capacity {
+			t.Fatalf("len %d exceeds capacity", cache.Len())
+		}
+		if total := cache.Len() + cache.b1.length + cache.b2.length; total > 2*capacity || total != len(cache.store) {
+			t.Fatalf("total %d, store %d", total, len(cache.store))
+		}
+		if cache.p < 0 || cache.p > capacity {
+			t.Fatalf("p %d out of range", cache.p)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"github.com/qieguo2016/data_structure/array"
+)
+
+// ArenaCache 基于array.RingBuf的[]byte缓存，参考freecache实现，几乎没有GC开销
+// 原理：按key的hash分到256个segment，每个segment一把锁、一个RingBuf和一张索引表
+//   - 数据：entry头部+key+value顺序写入RingBuf，不产生任何指针
+//   - 索引：每个segment有256个slot，slot内是按hash16排序的arenaPtr数组，记录entry在RingBuf中的偏移量，二分查找
+//   - 淘汰：RingBuf写满后从最旧的entry开始回收，访问时间比平均值新的entry通过Evacuate搬到尾部继续保留，
+//     其余的直接淘汰，效果近似LRU
+//   - 删除：只在entry头部打删除标记，等回收到这个位置时再跳过
+// 整个缓存只有少量大块的[]byte，百万级别的entry也不会给GC带来压力
+// ref: https://github.com/coocood/freecache
+
+const (
+	arenaSegmentCount           = 256
+	arenaHeaderSize             = 20
+	arenaMinSize                = 512 * 1024
+	arenaMaxConsecutiveEvacuate = 5
+)
+
+var (
+	ErrArenaLargeKey   = errors.New("cache: key is larger than 65535")
+	ErrArenaLargeEntry = errors.New("cache: entry is larger than 1/1024 of cache size")
+	ErrArenaNotFound   = errors.New("cache: entry not found")
+)
+
+// arenaHeader entry头部，固定20字节
+//
+//	0: accessTime uint32
+//	4: valLen     uint32
+//	8: valCap     uint32 value预留的空间，原地更新时可以复用
+//	12: keyLen    uint16
+//	14: hash16    uint16
+//	16: slotID    uint8
+//	17: deleted   uint8
+type arenaHeader struct {
+	accessTime uint32
+	valLen     uint32
+	valCap     uint32
+	keyLen     uint16
+	hash16     uint16
+	slotID     uint8
+	deleted    bool
+}
+
+func (h *arenaHeader) encode(buf []byte) {
+	binary.LittleEndian.PutUint32(buf[0:], h.accessTime)
+	binary.LittleEndian.PutUint32(buf[4:], h.valLen)
+	binary.LittleEndian.PutUint32(buf[8:], h.valCap)
+	binary.LittleEndian.PutUint16(buf[12:], h.keyLen)
+	binary.LittleEndian.PutUint16(buf[14:], h.hash16)
+	buf[16] = h.slotID
+	buf[17] = 0
+	if h.deleted {
+		buf[17] = 1
+	}
+	buf[18] = 0
+	buf[19] = 0
+}
+
+func (h *arenaHeader) decode(buf []byte) {
+	h.accessTime = binary.LittleEndian.Uint32(buf[0:])
+	h.valLen = binary.LittleEndian.Uint32(buf[4:])
+	h.valCap = binary.LittleEndian.Uint32(buf[8:])
+	h.keyLen = binary.LittleEndian.Uint16(buf[12:])
+	h.hash16 = binary.LittleEndian.Uint16(buf[14:])
+	h.slotID = buf[16]
+	h.deleted = buf[17] == 1
+}
+
+// entryLen entry在RingBuf中占用的总长度
+func (h *arenaHeader) entryLen() int64 {
+	return arenaHeaderSize + int64(h.keyLen) + int64(h.valCap)
+}
+
+// arenaPtr 索引项，记录entry在RingBuf中的偏移量
+type arenaPtr struct {
+	offset int64
+	hash16 uint16
+	keyLen uint16
+}
+
+// arenaSegment 分段，独立加锁
+type arenaSegment struct {
+	mu         sync.Mutex
+	rb         array.RingBuf
+	vacuumLen  int64 // RingBuf中可以回收的空间
+	totalTime  int64 // 所有entry访问时间之和，用来计算平均访问时间
+	totalCount int64 // RingBuf中的entry数量，包括已删除还未回收的
+	entryCount int64
+	slotLens   [256]int32
+	slotCap    int32
+	slotsData  []arenaPtr
+	hdrBuf     [arenaHeaderSize]byte
+	stats      Stats
+}
+
+func newArenaSegment(size int) *arenaSegment {
+	return &arenaSegment{
+		rb:        array.NewRingBuf(size),
+		vacuumLen: int64(size),
+		slotCap:   1,
+		slotsData: make([]arenaPtr, 256),
+	}
+}
+
+// slot 返回slotID对应的索引数组
+func (s *arenaSegment) slot(slotID uint8) []arenaPtr {
+	off := int32(slotID) * s.slotCap
+	return s.slotsData[off : off+s.slotLens[slotID] : off+s.slotCap]
+}
+
+// lookup 二分查找hash16，再逐个比较key，返回下标和是否找到
+func (s *arenaSegment) lookup(slot []arenaPtr, hash16 uint16, key []byte) (int, bool) {
+	lo, hi := 0, len(slot)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if slot[mid].hash16 < hash16 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	for idx := lo; idx < len(slot) && slot[idx].hash16 == hash16; idx++ {
+		ptr := &slot[idx]
+		if int(ptr.keyLen) == len(key) && s.rb.EqualAt(key, ptr.offset+arenaHeaderSize) {
+			return idx, true
+		}
+	}
+	return lo, false
+}
+
+// lookupByOffset 按偏移量查找索引项
+func (s *arenaSegment) lookupByOffset(slot []arenaPtr, hash16 uint16, offset int64) (int, bool) {
+	lo, hi := 0, len(slot)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if slot[mid].hash16 < hash16 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	for idx := lo; idx < len(slot) && slot[idx].hash16 == hash16; idx++ {
+		if slot[idx].offset == offset {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// expand slot容量翻倍
+func (s *arenaSegment) expand() {
+	newData := make([]arenaPtr, len(s.slotsData)*2)
+	for i := 0; i < 256; i++ {
+		off := int32(i) * s.slotCap
+		copy(newData[off*2:], s.slotsData[off:off+s.slotLens[i]])
+	}
+	s.slotCap *= 2
+	s.slotsData = newData
+}
+
+// insertPtr 在idx位置插入索引项
+func (s *arenaSegment) insertPtr(slotID uint8, idx int, hash16 uint16, offset int64, keyLen uint16) {
+	if s.slotLens[slotID] == s.slotCap {
+		s.expand()
+	}
+	s.slotLens[slotID]++
+	s.entryCount++
+	slot := s.slot(slotID)
+	copy(slot[idx+1:], slot[idx:])
+	slot[idx] = arenaPtr{offset: offset, hash16: hash16, keyLen: keyLen}
+}
+
+// deletePtr 删除idx位置的索引项，并给entry打上删除标记
+func (s *arenaSegment) deletePtr(slotID uint8, idx int) {
+	slot := s.slot(slotID)
+	offset := slot[idx].offset
+	s.rb.ReadAt(s.hdrBuf[:], offset)
+	s.hdrBuf[17] = 1
+	s.rb.WriteAt(s.hdrBuf[:], offset)
+	copy(slot[idx:], slot[idx+1:])
+	s.slotLens[slotID]--
+	s.entryCount--
+}
+
+// evacuate 回收最旧的entry直到能写下entryLen，返回slotID对应的索引是否被修改
+func (s *arenaSegment) evacuate(entryLen int64, slotID uint8) (slotModified bool) {
+	var hdr arenaHeader
+	consecutive := 0
+	for s.vacuumLen < entryLen {
+		oldOff := s.rb.End() + s.vacuumLen - s.rb.Size()
+		s.rb.ReadAt(s.hdrBuf[:], oldOff)
+		hdr.decode(s.hdrBuf[:])
+		oldLen := hdr.entryLen()
+		if hdr.deleted {
+			consecutive = 0
+			s.totalTime -= int64(hdr.accessTime)
+			s.totalCount--
+			s.vacuumLen += oldLen
+			continue
+		}
+		// 访问时间不新于平均值，或者连续搬迁太多次，直接淘汰
+		leastRecentUsed := int64(hdr.accessTime)*s.totalCount <= s.totalTime
+		if leastRecentUsed || consecutive > arenaMaxConsecutiveEvacuate {
+			slot := s.slot(hdr.slotID)
+			if idx, ok := s.lookupByOffset(slot, hdr.hash16, oldOff); ok {
+				s.deletePtr(hdr.slotID, idx)
+			}
+			if hdr.slotID == slotID {
+				slotModified = true
+			}
+			consecutive = 0
+			s.totalTime -= int64(hdr.accessTime)
+			s.totalCount--
+			s.vacuumLen += oldLen
+			s.stats.Evictions++
+			continue
+		}
+		// 搬到尾部，更新索引中的偏移量
+		newOff := s.rb.Evacuate(oldOff, int(oldLen))
+		slot := s.slot(hdr.slotID)
+		if idx, ok := s.lookupByOffset(slot, hdr.hash16, oldOff); ok {
+			slot[idx].offset = newOff
+		}
+		consecutive++
+	}
+	return
+}
+
+func (s *arenaSegment) set(key, value []byte, hash uint64, now uint32) error {
+	slotID := uint8(hash >> 8)
+	hash16 := uint16(hash >> 16)
+	s.stats.Puts++
+
+	var hdr arenaHeader
+	slot := s.slot(slotID)
+	idx, match := s.lookup(slot, hash16, key)
+	if match {
+		offset := slot[idx].offset
+		s.rb.ReadAt(s.hdrBuf[:], offset)
+		hdr.decode(s.hdrBuf[:])
+		if int(hdr.valCap) >= len(value) {
+			// 预留空间足够，原地更新
+			s.totalTime += int64(now) - int64(hdr.accessTime)
+			hdr.accessTime = now
+			hdr.valLen = uint32(len(value))
+			hdr.encode(s.hdrBuf[:])
+			s.rb.WriteAt(s.hdrBuf[:], offset)
+			s.rb.WriteAt(value, offset+arenaHeaderSize+int64(hdr.keyLen))
+			return nil
+		}
+		s.deletePtr(slotID, idx)
+		// 预留空间翻倍，减少下次变长时的重写
+		for int(hdr.valCap) < len(value) {
+			hdr.valCap *= 2
+		}
+	} else {
+		hdr.valCap = uint32(len(value))
+	}
+	hdr.accessTime = now
+	hdr.keyLen = uint16(len(key))
+	hdr.hash16 = hash16
+	hdr.slotID = slotID
+	hdr.valLen = uint32(len(value))
+	hdr.deleted = false
+	if hdr.valCap == 0 {
+		hdr.valCap = 1
+	}
+	entryLen := hdr.entryLen()
+	if entryLen > s.rb.Size() {
+		return ErrArenaLargeEntry
+	}
+	if s.evacuate(entryLen, slotID) || match {
+		slot = s.slot(slotID)
+		idx, _ = s.lookup(slot, hash16, key)
+	}
+	newOff := s.rb.End()
+	s.insertPtr(slotID, idx, hash16, newOff, hdr.keyLen)
+	hdr.encode(s.hdrBuf[:])
+	s.rb.Write(s.hdrBuf[:])
+	s.rb.Write(key)
+	s.rb.Write(value)
+	s.rb.Skip(int64(hdr.valCap - hdr.valLen))
+	s.totalTime += int64(now)
+	s.totalCount++
+	s.vacuumLen -= entryLen
+	return nil
+}
+
+func (s *arenaSegment) get(key []byte, hash uint64, now uint32) ([]byte, error) {
+	slotID := uint8(hash >> 8)
+	hash16 := uint16(hash >> 16)
+	slot := s.slot(slotID)
+	idx, match := s.lookup(slot, hash16, key)
+	if !match {
+		s.stats.Misses++
+		return nil, ErrArenaNotFound
+	}
+	s.stats.Hits++
+	offset := slot[idx].offset
+	var hdr arenaHeader
+	s.rb.ReadAt(s.hdrBuf[:], offset)
+	hdr.decode(s.hdrBuf[:])
+	s.totalTime += int64(now) - int64(hdr.accessTime)
+	hdr.accessTime = now
+	hdr.encode(s.hdrBuf[:])
+	s.rb.WriteAt(s.hdrBuf[:], offset)
+	value := make([]byte, hdr.valLen)
+	s.rb.ReadAt(value, offset+arenaHeaderSize+int64(hdr.keyLen))
+	return value, nil
+}
+
+func (s *arenaSegment) del(key []byte, hash uint64) bool {
+	slotID := uint8(hash >> 8)
+	hash16 := uint16(hash >> 16)
+	idx, match := s.lookup(s.slot(slotID), hash16, key)
+	if !match {
+		return false
+	}
+	s.deletePtr(slotID, idx)
+	return true
+}
+
+// ArenaCache 结构
+type ArenaCache struct {
+	seed     maphash.Seed
+	segments [arenaSegmentCount]*arenaSegment
+	now      func() uint32
+}
+
+// NewArenaCache constructor，size为RingBuf的总字节数，最小512KB
+func NewArenaCache(size int) *ArenaCache {
+	size = max(size, arenaMinSize)
+	c := &ArenaCache{
+		seed: maphash.MakeSeed(),
+		now:  func() uint32 { return uint32(time.Now().Unix()) },
+	}
+	for i := range c.segments {
+		c.segments[i] = newArenaSegment(size / arenaSegmentCount)
+	}
+	return c
+}
+
+// segment 低8位定位segment，8-16位定位slot，16-32位作为hash16
+func (c *ArenaCache) segment(key []byte) (*arenaSegment, uint64) {
+	hash := maphash.Bytes(c.seed, key)
+	return c.segments[hash&(arenaSegmentCount-1)], hash
+}
+
+// Set 写入kv，key和value会被拷贝到RingBuf中
+func (c *ArenaCache) Set(key, value []byte) error {
+	if len(key) > 65535 {
+		return ErrArenaLargeKey
+	}
+	s, hash := c.segment(key)
+	if int64(arenaHeaderSize+len(key)+len(value)) > s.rb.Size()/4 {
+		return ErrArenaLargeEntry
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(key, value, hash, c.now())
+}
+
+// Get 获取value的拷贝，不存在时返回ErrArenaNotFound
+func (c *ArenaCache) Get(key []byte) ([]byte, error) {
+	s, hash := c.segment(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key, hash, c.now())
+}
+
+// Delete 删除key，返回key是否存在
+func (c *ArenaCache) Delete(key []byte) bool {
+	s, hash := c.segment(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.del(key, hash)
+}
+
+// Len 当前存储的key数量
+func (c *ArenaCache) Len() int {
+	n := 0
+	for _, s := range c.segments {
+		s.mu.Lock()
+		n += int(s.entryCount)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Stats 汇总所有segment的统计，Evictions为回收RingBuf空间时淘汰的entry数量
+func (c *ArenaCache) Stats() Stats {
+	var stats Stats
+	for _, s := range c.segments {
+		s.mu.Lock()
+		stats.add(s.stats)
+		s.mu.Unlock()
+	}
+	return stats
+}
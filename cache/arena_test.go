@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestArenaCache(t *testing.T) {
+	c := NewArenaCache(0)
+	if err := c.Set([]byte("a"), []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get([]byte("a")); err != nil || string(v) != "hello" {
+		t.Errorf("get a expect hello, got %q, %v", v, err)
+	}
+	// 原地更新，value变短
+	c.Set([]byte("a"), []byte("hi"))
+	if v, _ := c.Get([]byte("a")); string(v) != "hi" {
+		t.Errorf("get a expect hi, got %q", v)
+	}
+	// value变长，重新写入
+	c.Set([]byte("a"), []byte("hello world"))
+	if v, _ := c.Get([]byte("a")); string(v) != "hello world" {
+		t.Errorf("get a expect hello world, got %q", v)
+	}
+	c.Set([]byte("b"), nil)
+	if v, err := c.Get([]byte("b")); err != nil || len(v) != 0 {
+		t.Errorf("get b expect empty value, got %q, %v", v, err)
+	}
+	if c.Len() != 2 {
+		t.Errorf("len expect 2, got %d", c.Len())
+	}
+	if !c.Delete([]byte("a")) || c.Delete([]byte("a")) {
+		t.Errorf("delete a should succeed only once")
+	}
+	if _, err := c.Get([]byte("a")); err != ErrArenaNotFound {
+		t.Errorf("get a after delete expect not found, got %v", err)
+	}
+	if err := c.Set(make([]byte, 65536), nil); err != ErrArenaLargeKey {
+		t.Errorf("large key expect ErrArenaLargeKey, got %v", err)
+	}
+	if err := c.Set([]byte("big"), make([]byte, 1024)); err != ErrArenaLargeEntry {
+		t.Errorf("large entry expect ErrArenaLargeEntry, got %v", err)
+	}
+	stats := c.Stats()
+	if stats.Hits != 4 || stats.Misses != 1 || stats.Puts != 4 {
+		t.Errorf("stats expect 4 hits, 1 miss, 4 puts, got %+v", stats)
+	}
+}
+
+func TestArenaCacheModel(t *testing.T) {
+	c := NewArenaCache(0)
+	model := map[string][]byte{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200000; i++ {
+		key := fmt.Sprintf("key-%d", r.Intn(20000))
+		switch r.Intn(10) {
+		case 0:
+			c.Delete([]byte(key))
+			delete(model, key)
+		case 1, 2, 3, 4:
+			value := make([]byte, r.Intn(200))
+			r.Read(value)
+			if err := c.Set([]byte(key), value); err != nil {
+				t.Fatalf("set %s: %v", key, err)
+			}
+			model[key] = value
+		default:
+			v, err := c.Get([]byte(key))
+			expect, exist := model[key]
+			if err == nil && (!exist || !bytes.Equal(v, expect)) {
+				t.Fatalf("get %s got stale value, exist in model %v", key, exist)
+			}
+			if err != nil && err != ErrArenaNotFound {
+				t.Fatalf("get %s: %v", key, err)
+			}
+		}
+	}
+	if c.Stats().Evictions == 0 {
+		t.Errorf("arena should be full and evict entries")
+	}
+	if n := c.Len(); n == 0 || n > len(model) {
+		t.Errorf("len %d out of range, model %d", n, len(model))
+	}
+}
+
+func TestArenaCacheKeepHot(t *testing.T) {
+	c := NewArenaCache(0)
+	now := uint32(1)
+	c.now = func() uint32 { return now }
+	value := make([]byte, 100)
+	for i := 0; i < 100; i++ {
+		c.Set([]byte(fmt.Sprintf("hot-%d", i)), value)
+	}
+	// 写满RingBuf，期间不断访问热点key，热点key的访问时间比平均值新，回收时会被搬到尾部保留
+	for i := 0; i < 50000; i++ {
+		now++
+		c.Set([]byte(fmt.Sprintf("cold-%d", i)), value)
+		if i%100 == 0 {
+			for j := 0; j < 100; j++ {
+				c.Get([]byte(fmt.Sprintf("hot-%d", j)))
+			}
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := c.Get([]byte(fmt.Sprintf("hot-%d", i))); err != nil {
+			t.Errorf("hot-%d should survive: %v", i, err)
+		}
+	}
+	if _, err := c.Get([]byte("cold-0")); err != ErrArenaNotFound {
+		t.Errorf("cold-0 should be evicted, got %v", err)
+	}
+}
+
+func BenchmarkArenaCacheSet(b *testing.B) {
+	c := NewArenaCache(64 * 1024 * 1024)
+	key := make([]byte, 8)
+	value := make([]byte, 64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key[0], key[1], key[2], key[3] = byte(i), byte(i>>8), byte(i>>16), byte(i>>24)
+		c.Set(key, value)
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// benchCache 各个淘汰策略和benchmark对照组共同的方法
+type benchCache interface {
+	Put(key int, value int)
+	Get(key int) (int, bool)
+}
+
+// benchmarkPolicy 热点场景：所有key都在缓存中，随机访问，只测命中路径
+func benchmarkPolicy(b *testing.B, c benchCache, keys int) {
+	for i := 0; i < keys; i++ {
+		c.Put(i, i)
+	}
+	r := rand.New(rand.NewSource(1))
+	trace := make([]int, 1<<16)
+	for i := range trace {
+		trace[i] = r.Intn(keys)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Get(trace[i&(len(trace)-1)])
+	}
+}
+
+// benchmarkChurn 容量不足时混合读写，触发淘汰
+func benchmarkChurn(b *testing.B, c benchCache, keys int) {
+	r := rand.New(rand.NewSource(1))
+	trace := make([]int, 1<<16)
+	for i := range trace {
+		trace[i] = r.Intn(keys * 2)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := trace[i&(len(trace)-1)]
+		if _, ok := c.Get(k); !ok {
+			c.Put(k, k)
+		}
+	}
+}
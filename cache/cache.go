@@ -0,0 +1,37 @@
+package cache
+
+// Cache 各个淘汰策略共同实现的方法
+// 除了LRUCache和ShardedCache，其余实现都不是并发安全的，需要调用方加锁
+type Cache[K comparable, V any] interface {
+	// Get 获取kv，会影响淘汰顺序并计入命中统计
+	Get(key K) (V, bool)
+	// Peek 获取kv，不影响淘汰顺序也不计入统计
+	Peek(key K) (V, bool)
+	// Contains 判断key是否存在，不影响淘汰顺序
+	Contains(key K) bool
+	// Put 插入或覆盖kv，容量不足时按策略淘汰
+	Put(key K, value V)
+	// Delete 删除key，不存在时什么都不做
+	Delete(key K)
+	// Len 当前存储的key数量
+	Len() int
+	// Keys 返回所有key，顺序由具体策略决定
+	Keys() []K
+	// Stats 返回统计快照
+	Stats() Stats
+	// OnEvict 设置淘汰回调
+	OnEvict(fn EvictFunc[K, V])
+}
+
+var (
+	_ Cache[int, int] = (*LRUCache[int, int])(nil)
+	_ Cache[int, int] = (*LFUCache[int, int])(nil)
+	_ Cache[int, int] = (*ARCCache[int, int])(nil)
+	_ Cache[int, int] = (*TinyLFUCache[int, int])(nil)
+	_ Cache[int, int] = (*SLRUCache[int, int])(nil)
+	_ Cache[int, int] = (*LRUKCache[int, int])(nil)
+	_ Cache[int, int] = (*ClockCache[int, int])(nil)
+	_ Cache[int, int] = (*SIEVECache[int, int])(nil)
+	_ Cache[int, int] = (*S3FIFOCache[int, int])(nil)
+	_ Cache[int, int] = (*ShardedCache[int, int])(nil)
+)
@@ -0,0 +1,141 @@
+package cache
+
+// ClockCache CLOCK缓存(second chance)
+// 原理：所有key放在一个环形数组中，命中时只设置访问位，不移动任何节点
+// 需要淘汰时时钟指针沿环转动，访问位为1的key清零后获得第二次机会，遇到访问位为0的key则淘汰并复用它的槽位
+// 是LRU的近似，命中路径上没有链表操作和内存分配
+// ref: https://en.wikipedia.org/wiki/Page_replacement_algorithm#Clock
+
+// clockSlot 环形数组中的槽位
+type clockSlot[K comparable, V any] struct {
+	key        K
+	value      V
+	referenced bool
+}
+
+// ClockCache 结构
+type ClockCache[K comparable, V any] struct {
+	metrics[K, V]
+	capacity int
+	hand     int // 时钟指针
+	slots    []clockSlot[K, V]
+	store    map[K]int // key到槽位下标
+	free     []int     // 删除后空出的槽位
+}
+
+// NewClockCache constructor
+func NewClockCache[K comparable, V any](capacity int) *ClockCache[K, V] {
+	return &ClockCache[K, V]{
+		capacity: capacity,
+		slots:    make([]clockSlot[K, V], 0, max(capacity, 0)),
+		store:    map[K]int{},
+	}
+}
+
+// remove 清空槽位
+func (c *ClockCache[K, V]) remove(index int, reason EvictReason) {
+	slot := c.slots[index]
+	c.slots[index] = clockSlot[K, V]{}
+	delete(c.store, slot.key)
+	c.evicted(slot.key, slot.value, reason)
+}
+
+// evict 转动时钟指针淘汰一个key，返回空出的槽位
+func (c *ClockCache[K, V]) evict() int {
+	for {
+		index := c.hand
+		c.hand = (c.hand + 1) % len(c.slots)
+		if c.slots[index].referenced {
+			c.slots[index].referenced = false
+			continue
+		}
+		c.remove(index, EvictCapacity)
+		return index
+	}
+}
+
+// Delete 删除key
+func (c *ClockCache[K, V]) Delete(key K) {
+	if index, exist := c.store[key]; exist {
+		c.remove(index, EvictDelete)
+		c.free = append(c.free, index)
+	}
+}
+
+// Get 获取kv，命中时设置访问位
+func (c *ClockCache[K, V]) Get(key K) (value V, ok bool) {
+	index, exist := c.store[key]
+	if !exist {
+		c.miss()
+		return
+	}
+	c.hit()
+	c.slots[index].referenced = true
+	return c.slots[index].value, true
+}
+
+// Peek 获取kv，不设置访问位
+func (c *ClockCache[K, V]) Peek(key K) (value V, ok bool) {
+	index, exist := c.store[key]
+	if !exist {
+		return
+	}
+	return c.slots[index].value, true
+}
+
+// Contains 判断key是否存在，不设置访问位
+func (c *ClockCache[K, V]) Contains(key K) bool {
+	_, exist := c.store[key]
+	return exist
+}
+
+// Put 插入，已存在的key视为一次命中，新key的访问位为0
+func (c *ClockCache[K, V]) Put(key K, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.put()
+	if index, exist := c.store[key]; exist {
+		old := c.slots[index].value
+		c.slots[index].value = value
+		c.slots[index].referenced = true
+		c.evicted(key, old, EvictReplace)
+		return
+	}
+	var index int
+	switch {
+	case len(c.free) > 0:
+		index = c.free[len(c.free)-1]
+		c.free = c.free[:len(c.free)-1]
+	case len(c.slots) < c.capacity:
+		index = len(c.slots)
+		c.slots = append(c.slots, clockSlot[K, V]{})
+	default:
+		index = c.evict()
+	}
+	c.slots[index] = clockSlot[K, V]{key: key, value: value}
+	c.store[key] = index
+}
+
+// Len 当前存储的key数量
+func (c *ClockCache[K, V]) Len() int {
+	return len(c.store)
+}
+
+// Keys 返回所有key，从时钟指针开始按扫描顺序排列
+func (c *ClockCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.store))
+	for i := range c.slots {
+		index := (c.hand + i) % len(c.slots)
+		if k := c.slots[index].key; c.isUsed(index, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// isUsed 槽位是否存放着key
+func (c *ClockCache[K, V]) isUsed(index int, key K) bool {
+	i, exist := c.store[key]
+	return exist && i == index
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClockSecondChance(t *testing.T) {
+	cache := NewClockCache[int, int](3)
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	cache.Put(3, 3)
+	cache.Get(1)
+	// 1的访问位为1获得第二次机会，淘汰2
+	cache.Put(4, 4)
+	if cache.Contains(2) || !cache.Contains(1) {
+		t.Errorf("2 should be evicted, keys %v", cache.Keys())
+	}
+	// 指针停在3，1的访问位已经清零
+	if got, want := cache.Keys(), []int{3, 1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+	cache.Put(5, 5)
+	if cache.Contains(3) {
+		t.Errorf("3 should be evicted, keys %v", cache.Keys())
+	}
+	// 删除空出的槽位被复用，不触发淘汰
+	cache.Delete(1)
+	cache.Put(6, 6)
+	if cache.Len() != 3 || !cache.Contains(4) || !cache.Contains(5) || !cache.Contains(6) {
+		t.Errorf("keys expect 4 5 6, got %v", cache.Keys())
+	}
+}
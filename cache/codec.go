@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec 快照中key/value的编解码
+type Codec[T any] interface {
+	Marshal(v T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// GobCodec 使用encoding/gob编解码，每个值单独编码，会带上类型信息
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Marshal(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Unmarshal(data []byte) (v T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return
+}
+
+// JSONCodec 使用encoding/json编解码
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[T]) Unmarshal(data []byte) (v T, err error) {
+	err = json.Unmarshal(data, &v)
+	return
+}
+
+// BytesCodec []byte原样写入
+type BytesCodec struct{}
+
+func (BytesCodec) Marshal(v []byte) ([]byte, error) {
+	return v, nil
+}
+
+func (BytesCodec) Unmarshal(data []byte) ([]byte, error) {
+	return bytes.Clone(data), nil
+}
+
+// StringCodec string原样写入
+type StringCodec struct{}
+
+func (StringCodec) Marshal(v string) ([]byte, error) {
+	return []byte(v), nil
+}
+
+func (StringCodec) Unmarshal(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// codecs 内嵌到支持快照的缓存中，未设置时默认使用gob
+type codecs[K comparable, V any] struct {
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+}
+
+// SetCodec 设置快照使用的key/value编解码，nil表示使用gob，需要在Snapshot/Restore之前设置
+func (c *codecs[K, V]) SetCodec(key Codec[K], value Codec[V]) {
+	c.keyCodec = key
+	c.valueCodec = value
+}
+
+func (c *codecs[K, V]) keys() Codec[K] {
+	if c.keyCodec == nil {
+		return GobCodec[K]{}
+	}
+	return c.keyCodec
+}
+
+func (c *codecs[K, V]) values() Codec[V] {
+	if c.valueCodec == nil {
+		return GobCodec[V]{}
+	}
+	return c.valueCodec
+}
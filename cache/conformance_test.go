@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// cacheCases 所有实现了Cache的淘汰策略，新增策略需要在这里注册
+var cacheCases = []struct {
+	name     string
+	newCache func(capacity int) Cache[int, int]
+}{
+	{"lru", func(capacity int) Cache[int, int] { return NewLRUCache[int, int](capacity) }},
+	{"lfu", func(capacity int) Cache[int, int] { return NewLFUCache[int, int](capacity) }},
+	{"arc", func(capacity int) Cache[int, int] { return NewARCCache[int, int](capacity) }},
+	{"tinylfu", func(capacity int) Cache[int, int] { return NewTinyLFUCache[int, int](capacity) }},
+	{"slru", func(capacity int) Cache[int, int] { return NewSLRUCache[int, int](capacity, 0.5) }},
+	{"lruk", func(capacity int) Cache[int, int] { return NewLRUKCache[int, int](capacity, 2, capacity) }},
+	{"clock", func(capacity int) Cache[int, int] { return NewClockCache[int, int](capacity) }},
+	{"sieve", func(capacity int) Cache[int, int] { return NewSIEVECache[int, int](capacity) }},
+	{"s3fifo", func(capacity int) Cache[int, int] { return NewS3FIFOCache[int, int](capacity) }},
+	{"sharded", func(capacity int) Cache[int, int] { return NewShardedCache[int, int](1, capacity, PolicyLRU) }},
+}
+
+func TestConformance(t *testing.T) {
+	for _, tc := range cacheCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCacheConformance(t, tc.newCache)
+		})
+	}
+}
+
+// testCacheConformance 所有策略都要满足的行为，和具体的淘汰顺序无关
+func testCacheConformance(t *testing.T, newCache func(capacity int) Cache[int, int]) {
+	t.Run("capacity", func(t *testing.T) {
+		for _, capacity := range []int{1, 2, 10, 100} {
+			cache := newCache(capacity)
+			for i := 0; i < capacity*10; i++ {
+				cache.Put(i, i)
+				// 刚写入的key一定存在
+				if v, ok := cache.Peek(i); !ok || v != i {
+					t.Fatalf("capacity %d: peek %d just put, got %v, %v", capacity, i, v, ok)
+				}
+				if cache.Len() > capacity {
+					t.Fatalf("capacity %d: len %d exceeds capacity", capacity, cache.Len())
+				}
+			}
+			if cache.Len() != capacity {
+				t.Errorf("capacity %d: len expect %d, got %d", capacity, capacity, cache.Len())
+			}
+		}
+		cache := newCache(0)
+		cache.Put(1, 1)
+		if cache.Len() != 0 || cache.Contains(1) {
+			t.Errorf("zero capacity cache should stay empty")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		cache := newCache(4)
+		var reasons []EvictReason
+		cache.OnEvict(func(key int, value int, reason EvictReason) {
+			reasons = append(reasons, reason)
+		})
+		cache.Put(1, 1)
+		cache.Put(2, 2)
+		cache.Delete(1)
+		cache.Delete(1)
+		cache.Delete(3)
+		if cache.Contains(1) || !cache.Contains(2) || cache.Len() != 1 {
+			t.Errorf("delete 1 failed, keys %v", cache.Keys())
+		}
+		if len(reasons) != 1 || reasons[0] != EvictDelete {
+			t.Errorf("delete should fire callback once, got %v", reasons)
+		}
+		if _, ok := cache.Get(1); ok {
+			t.Errorf("get deleted key should miss")
+		}
+		// 删除后可以重新写入
+		cache.Put(1, 10)
+		if v, ok := cache.Get(1); !ok || v != 10 {
+			t.Errorf("get 1 expect 10 after re-put, got %v, %v", v, ok)
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		cache := newCache(4)
+		var olds []int
+		cache.OnEvict(func(key int, value int, reason EvictReason) {
+			if reason == EvictReplace {
+				olds = append(olds, value)
+			}
+		})
+		cache.Put(1, 1)
+		cache.Put(2, 2)
+		cache.Put(1, 10)
+		if v, ok := cache.Get(1); !ok || v != 10 {
+			t.Errorf("get 1 expect 10, got %v, %v", v, ok)
+		}
+		if cache.Len() != 2 {
+			t.Errorf("overwrite should not change len, got %d", cache.Len())
+		}
+		if len(olds) != 1 || olds[0] != 1 {
+			t.Errorf("overwrite should report old value 1, got %v", olds)
+		}
+	})
+
+	t.Run("peek", func(t *testing.T) {
+		cache := newCache(4)
+		cache.Put(1, 1)
+		before := cache.Stats()
+		cache.Peek(1)
+		cache.Peek(2)
+		cache.Contains(1)
+		if cache.Stats() != before {
+			t.Errorf("peek should not change stats, %+v -> %+v", before, cache.Stats())
+		}
+	})
+
+	t.Run("model", func(t *testing.T) {
+		testCacheModel(t, newCache)
+	})
+}
+
+// testCacheModel 随机操作，用map作为参照模型
+// 模型记录缓存应有的全部内容：Put写入，Delete和淘汰回调删除，每一步之后缓存内容必须和模型完全一致
+func testCacheModel(t *testing.T, newCache func(capacity int) Cache[int, int]) {
+	const capacity, keys, ops = 16, 64, 20000
+	r := rand.New(rand.NewSource(1))
+	cache := newCache(capacity)
+	model := map[int]int{}
+	cache.OnEvict(func(key int, value int, reason EvictReason) {
+		if reason == EvictReplace {
+			return
+		}
+		if v, ok := model[key]; !ok || v != value {
+			t.Fatalf("evict %d=%d (%v), model has %d, %v", key, value, reason, v, ok)
+		}
+		delete(model, key)
+	})
+	var hits, misses uint64
+	for i := 0; i < ops; i++ {
+		key := r.Intn(keys)
+		switch op := r.Intn(10); {
+		case op < 5:
+			v, ok := cache.Get(key)
+			want, exist := model[key]
+			if ok != exist || ok && v != want {
+				t.Fatalf("op %d: get %d got %v, %v, want %v, %v", i, key, v, ok, want, exist)
+			}
+			if ok {
+				hits++
+			} else {
+				misses++
+			}
+		case op < 9:
+			// 先写模型，Put过程中淘汰了新key也能从模型中删掉
+			model[key] = i
+			cache.Put(key, i)
+		default:
+			cache.Delete(key)
+			delete(model, key)
+		}
+		if cache.Len() != len(model) || len(model) > capacity {
+			t.Fatalf("op %d: len %d, model len %d", i, cache.Len(), len(model))
+		}
+	}
+	got := cache.Keys()
+	want := make([]int, 0, len(model))
+	for k, v := range model {
+		want = append(want, k)
+		if pv, ok := cache.Peek(k); !ok || pv != v {
+			t.Errorf("peek %d got %v, %v, want %v", k, pv, ok, v)
+		}
+	}
+	sort.Ints(got)
+	sort.Ints(want)
+	if len(got) != len(want) {
+		t.Fatalf("keys expect %v, got %v", want, got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("keys expect %v, got %v", want, got)
+		}
+	}
+	stats := cache.Stats()
+	if stats.Hits != hits || stats.Misses != misses {
+		t.Errorf("stats expect %d hits %d misses, got %+v", hits, misses, stats)
+	}
+}
@@ -1,134 +1,334 @@
 package cache
 
-// LFUCache 基于内存实现
-// 原理：map结构按照kv存储数据，双向链表保存节点使用频率
+import "iter"
 
-// LFUChainNode 链表节点，按freq降序排列
-type LFUChainNode struct {
-	pre   *LFUChainNode
-	next  *LFUChainNode
-	key   int
-	value int
-	freq  int
+// LFUCache 基于内存实现，Get/Put/淘汰均为O(1)
+// 原理：map结构按照kv存储数据，频率桶组成双向链表按freq升序排列，每个桶内再用双向链表按新鲜度保存同频率的节点
+// 访问节点时从当前桶移到freq+1的桶表头，淘汰时取最低频率桶的表尾，即同频率下最久未使用的节点
+// 容量：默认每个key占1个单位，设置Weigher之后按开销淘汰，直到总开销不超过maxCost
+// ref: http://dhruvbird.com/lfu.pdf
+
+// LFUChainNode 链表节点，挂在所属频率桶的链表上
+type LFUChainNode[K comparable, V any] struct {
+	pre    *LFUChainNode[K, V]
+	next   *LFUChainNode[K, V]
+	key    K
+	value  V
+	cost   int64
+	bucket *lfuBucket[K, V]
 }
 
-// LFUCache 结构
-type LFUCache struct {
-	capacity int
-	length   int
-	store    map[int]*LFUChainNode
-	head     *LFUChainNode
-	tail     *LFUChainNode
+// lfuBucket 频率桶，桶内链表表头为最近使用，表尾为最久未使用
+type lfuBucket[K comparable, V any] struct {
+	pre  *lfuBucket[K, V]
+	next *lfuBucket[K, V]
+	freq int
+	head *LFUChainNode[K, V]
+	tail *LFUChainNode[K, V]
 }
 
-// NewLFUCache constructor
-func NewLFUCache(capacity int) LFUCache {
-	return LFUCache{
-		capacity: capacity,
-		length:   0,
-		store:    map[int]*LFUChainNode{},
+// unlink 将节点从桶中摘除
+func (b *lfuBucket[K, V]) unlink(node *LFUChainNode[K, V]) {
+	if node.pre == nil {
+		b.head = node.next
+	} else {
+		node.pre.next = node.next
+	}
+	if node.next == nil {
+		b.tail = node.pre
+	} else {
+		node.next.pre = node.pre
 	}
+	node.pre = nil
+	node.next = nil
+	node.bucket = nil
 }
 
-func (c *LFUCache) adjustNode(node *LFUChainNode) {
-	// 更新频率之后判断是否需要往前移动
-	for node.pre != nil && node.freq >= node.pre.freq {
-		pre1 := node.pre
-		pre0 := pre1.pre
-		next := node.next
+// pushFront 将节点插入到桶的表头
+func (b *lfuBucket[K, V]) pushFront(node *LFUChainNode[K, V]) {
+	node.pre = nil
+	node.next = b.head
+	if b.head != nil {
+		b.head.pre = node
+	}
+	b.head = node
+	if b.tail == nil {
+		b.tail = node
+	}
+	node.bucket = b
+}
 
-		// 调整元素
-		node.pre = pre0
-		if pre0 != nil {
-			pre0.next = node
-		}
-		node.next = pre1
-		pre1.pre = node
-		pre1.next = next
-		if next != nil {
-			next.pre = pre1
-		}
-		// 处理链表头部
-		if node.pre == nil { // 已经移动到了链表头
-			c.head = node
-		}
-		// 原来是链表尾
-		if next == nil {
-			c.tail = pre1
-		}
+// LFUCache 结构，head为最低频率的桶
+type LFUCache[K comparable, V any] struct {
+	metrics[K, V]
+	codecs[K, V]
+	length  int
+	cost    int64 // 当前总开销
+	maxCost int64 // 总开销上限
+	weigher Weigher[K, V]
+	store   map[K]*LFUChainNode[K, V]
+	head    *lfuBucket[K, V]
+	tail    *lfuBucket[K, V]
+}
+
+// NewLFUCache constructor
+func NewLFUCache[K comparable, V any](capacity int) *LFUCache[K, V] {
+	return &LFUCache[K, V]{
+		length:  0,
+		maxCost: int64(capacity),
+		store:   map[K]*LFUChainNode[K, V]{},
 	}
 }
 
-// Delete 删除key
-func (c *LFUCache) Delete(key int) {
-	node, exist := c.store[key]
-	if !exist {
-		return
+// NewLFUCacheWithWeigher constructor，按weigher计算每个kv的开销，总开销不超过maxCost
+func NewLFUCacheWithWeigher[K comparable, V any](maxCost int64, weigher Weigher[K, V]) *LFUCache[K, V] {
+	c := NewLFUCache[K, V](0)
+	c.maxCost = maxCost
+	c.weigher = weigher
+	return c
+}
+
+// insertBucketAfter 在pre之后插入频率为freq的新桶，pre为nil时插入到表头
+func (c *LFUCache[K, V]) insertBucketAfter(pre *lfuBucket[K, V], freq int) *lfuBucket[K, V] {
+	b := &lfuBucket[K, V]{freq: freq, pre: pre}
+	if pre == nil {
+		b.next = c.head
+		c.head = b
+	} else {
+		b.next = pre.next
+		pre.next = b
 	}
-	delete(c.store, key)
-	if c.length == 1 {
-		c.head = nil
-		c.tail = nil
-		c.length--
-		return
+	if b.next == nil {
+		c.tail = b
+	} else {
+		b.next.pre = b
 	}
-	// 头结点
-	if node.pre == nil {
-		c.head = c.head.next
-		c.head.pre = nil
+	return b
+}
+
+// removeBucket 删除空桶
+func (c *LFUCache[K, V]) removeBucket(b *lfuBucket[K, V]) {
+	if b.pre == nil {
+		c.head = b.next
 	} else {
-		node.pre.next = node.next
+		b.pre.next = b.next
 	}
-	// 尾部
-	if node.next == nil {
-		c.tail = c.tail.pre
-		c.tail.next = nil
+	if b.next == nil {
+		c.tail = b.pre
 	} else {
-		node.next.pre = node.pre
+		b.next.pre = b.pre
 	}
+	b.pre = nil
+	b.next = nil
+}
+
+// unlink 将节点从所属桶中摘除，桶空了顺带删除
+func (c *LFUCache[K, V]) unlink(node *LFUChainNode[K, V]) {
+	b := node.bucket
+	b.unlink(node)
+	if b.head == nil {
+		c.removeBucket(b)
+	}
+}
+
+// touch 访问节点，频率+1，移到下一个频率桶的表头
+func (c *LFUCache[K, V]) touch(node *LFUChainNode[K, V]) {
+	b := node.bucket
+	next := b.next
+	if next == nil || next.freq != b.freq+1 {
+		next = c.insertBucketAfter(b, b.freq+1)
+	}
+	c.unlink(node)
+	next.pushFront(node)
+}
+
+// remove 删除节点
+func (c *LFUCache[K, V]) remove(node *LFUChainNode[K, V], reason EvictReason) {
+	delete(c.store, node.key)
+	c.unlink(node)
 	c.length--
+	c.cost -= node.cost
+	c.evicted(node.key, node.value, reason)
+}
+
+// Delete 删除key
+func (c *LFUCache[K, V]) Delete(key K) {
+	if node, exist := c.store[key]; exist {
+		c.remove(node, EvictDelete)
+	}
 }
 
-// Get 获取kv，更新使用次数，调整链表顺序
-func (c *LFUCache) Get(key int) int {
+// Get 获取kv，更新使用次数
+func (c *LFUCache[K, V]) Get(key K) (value V, ok bool) {
 	node, exist := c.store[key]
 	if !exist {
-		return -1
+		c.miss()
+		return
 	}
-	node.freq++
-	c.adjustNode(node)
-	return node.value
+	c.hit()
+	c.touch(node)
+	return node.value, true
 }
 
-// Put 插入
-func (c *LFUCache) Put(key int, value int) {
-	if c.capacity == 0 {
+// Peek 获取kv，不更新使用次数
+func (c *LFUCache[K, V]) Peek(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
 		return
 	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不更新使用次数
+func (c *LFUCache[K, V]) Contains(key K) bool {
+	_, exist := c.store[key]
+	return exist
+}
+
+// weigh 计算kv的开销，未设置weigher时每个kv开销为1
+func (c *LFUCache[K, V]) weigh(key K, value V) int64 {
+	if c.weigher == nil {
+		return 1
+	}
+	return max(c.weigher(key, value), 0)
+}
+
+// evictOver 淘汰频率最低且最久未使用的key，即最低频率桶的表尾，直到能再放下cost的开销，不淘汰keep
+func (c *LFUCache[K, V]) evictOver(cost int64, keep *LFUChainNode[K, V]) {
+	for c.cost+cost > c.maxCost {
+		victim := c.head.tail
+		if victim == keep {
+			if victim.pre != nil {
+				victim = victim.pre
+			} else if c.head.next != nil {
+				victim = c.head.next.tail
+			} else {
+				return
+			}
+		}
+		c.remove(victim, EvictCapacity)
+	}
+}
+
+// Put 插入，开销超过maxCost的kv直接拒绝，已存在的旧值也会被删除
+func (c *LFUCache[K, V]) Put(key K, value V) {
+	if c.maxCost <= 0 {
+		return
+	}
+	c.put()
+	cost := c.weigh(key, value)
 	node, exist := c.store[key]
+	if cost > c.maxCost {
+		if exist {
+			c.remove(node, EvictReplace)
+		}
+		return
+	}
 	// 已经存在，刷新存储值，刷新频率
 	if exist {
+		old := node.value
 		node.value = value
-		node.freq++
-		c.adjustNode(node)
+		c.cost += cost - node.cost
+		node.cost = cost
+		c.touch(node)
+		c.evicted(key, old, EvictReplace)
+		c.evictOver(0, node)
 		return
 	}
 	// 不存在，新插入
-	// 超过容量的时候清理频率最低的key，即队尾
-	if c.length+1 > c.capacity {
-		c.Delete(c.tail.key)
+	// 超过容量的时候清理频率最低且最久未使用的key
+	c.evictOver(cost, nil)
+	node = &LFUChainNode[K, V]{key: key, value: value, cost: cost}
+	b := c.head
+	if b == nil || b.freq != 1 {
+		b = c.insertBucketAfter(nil, 1)
 	}
-	node = &LFUChainNode{key: key, value: value, freq: 1}
+	b.pushFront(node)
 	c.store[key] = node
 	c.length++
-	if c.length == 1 {
-		c.head = node
-		c.tail = node
-		return
+	c.cost += cost
+}
+
+// Cost 当前总开销，未设置weigher时等于key数量
+func (c *LFUCache[K, V]) Cost() int64 {
+	return c.cost
+}
+
+// Len 当前存储的key数量
+func (c *LFUCache[K, V]) Len() int {
+	return c.length
+}
+
+// Keys 按频率从高到低返回所有key，同频率按新鲜度从新到旧
+func (c *LFUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.length)
+	for b := c.tail; b != nil; b = b.pre {
+		for node := b.head; node != nil; node = node.next {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
+
+// All 按频率从高到低遍历所有kv，同频率按新鲜度从新到旧，不更新使用次数
+// 遍历的是调用时刻的快照，遍历过程中可以调用缓存的其他方法
+func (c *LFUCache[K, V]) All() iter.Seq2[K, V] {
+	keys := make([]K, 0, c.length)
+	values := make([]V, 0, c.length)
+	for b := c.tail; b != nil; b = b.pre {
+		for node := b.head; node != nil; node = node.next {
+			keys = append(keys, node.key)
+			values = append(values, node.value)
+		}
+	}
+	return func(yield func(K, V) bool) {
+		for i, key := range keys {
+			if !yield(key, values[i]) {
+				return
+			}
+		}
 	}
-	// 追加到尾部
-	c.tail.next = node
-	node.pre = c.tail
-	c.tail = node
-	c.adjustNode(node)
+}
+
+// GetMany 批量获取，返回命中的kv，效果等同于依次调用Get
+func (c *LFUCache[K, V]) GetMany(keys []K) map[K]V {
+	values := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if value, ok := c.Get(key); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// PutMany 按顺序批量插入
+func (c *LFUCache[K, V]) PutMany(kvs iter.Seq2[K, V]) {
+	for key, value := range kvs {
+		c.Put(key, value)
+	}
+}
+
+// DeleteFunc 删除所有满足条件的kv，返回删除数量
+func (c *LFUCache[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	n := 0
+	for b := c.head; b != nil; {
+		// 删除节点可能连带删除空桶，先记下后继
+		nextBucket := b.next
+		for node := b.head; node != nil; {
+			next := node.next
+			if pred(node.key, node.value) {
+				c.remove(node, EvictDelete)
+				n++
+			}
+			node = next
+		}
+		b = nextBucket
+	}
+	return n
+}
+
+// Resize 调整容量，未设置weigher时为key数量上限，否则为总开销上限，容量变小时立即淘汰到新容量以内
+func (c *LFUCache[K, V]) Resize(capacity int64) {
+	c.maxCost = max(capacity, 0)
+	c.evictOver(0, nil)
 }
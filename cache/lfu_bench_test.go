@@ -0,0 +1,170 @@
+package cache
+
+import "testing"
+
+// listLFUCache 旧版实现，频率链表中的节点每次访问后逐个往前交换，同频率key很多时单次访问为O(n)
+// 保留下来作为benchmark的对照组
+
+// listLFUNode 链表节点，按freq降序排列
+type listLFUNode struct {
+	pre   *listLFUNode
+	next  *listLFUNode
+	key   int
+	value int
+	freq  int
+}
+
+// listLFUCache 结构
+type listLFUCache struct {
+	capacity int
+	length   int
+	store    map[int]*listLFUNode
+	head     *listLFUNode
+	tail     *listLFUNode
+}
+
+// newListLFUCache constructor
+func newListLFUCache(capacity int) listLFUCache {
+	return listLFUCache{
+		capacity: capacity,
+		length:   0,
+		store:    map[int]*listLFUNode{},
+	}
+}
+
+func (c *listLFUCache) adjustNode(node *listLFUNode) {
+	// 更新频率之后判断是否需要往前移动
+	for node.pre != nil && node.freq >= node.pre.freq {
+		pre1 := node.pre
+		pre0 := pre1.pre
+		next := node.next
+
+		// 调整元素
+		node.pre = pre0
+		if pre0 != nil {
+			pre0.next = node
+		}
+		node.next = pre1
+		pre1.pre = node
+		pre1.next = next
+		if next != nil {
+			next.pre = pre1
+		}
+		// 处理链表头部
+		if node.pre == nil { // 已经移动到了链表头
+			c.head = node
+		}
+		// 原来是链表尾
+		if next == nil {
+			c.tail = pre1
+		}
+	}
+}
+
+// Delete 删除key
+func (c *listLFUCache) Delete(key int) {
+	node, exist := c.store[key]
+	if !exist {
+		return
+	}
+	delete(c.store, key)
+	if c.length == 1 {
+		c.head = nil
+		c.tail = nil
+		c.length--
+		return
+	}
+	// 头结点
+	if node.pre == nil {
+		c.head = c.head.next
+		c.head.pre = nil
+	} else {
+		node.pre.next = node.next
+	}
+	// 尾部
+	if node.next == nil {
+		c.tail = c.tail.pre
+		c.tail.next = nil
+	} else {
+		node.next.pre = node.pre
+	}
+	c.length--
+}
+
+// Get 获取kv，更新使用次数，调整链表顺序
+func (c *listLFUCache) Get(key int) int {
+	node, exist := c.store[key]
+	if !exist {
+		return -1
+	}
+	node.freq++
+	c.adjustNode(node)
+	return node.value
+}
+
+// Put 插入
+func (c *listLFUCache) Put(key int, value int) {
+	if c.capacity == 0 {
+		return
+	}
+	node, exist := c.store[key]
+	// 已经存在，刷新存储值，刷新频率
+	if exist {
+		node.value = value
+		node.freq++
+		c.adjustNode(node)
+		return
+	}
+	// 不存在，新插入
+	// 超过容量的时候清理频率最低的key，即队尾
+	if c.length+1 > c.capacity {
+		c.Delete(c.tail.key)
+	}
+	node = &listLFUNode{key: key, value: value, freq: 1}
+	c.store[key] = node
+	c.length++
+	if c.length == 1 {
+		c.head = node
+		c.tail = node
+		return
+	}
+	// 追加到尾部
+	c.tail.next = node
+	node.pre = c.tail
+	c.tail = node
+	c.adjustNode(node)
+}
+
+// listLFUBench 适配对照组的Get返回值
+type listLFUBench struct {
+	c listLFUCache
+}
+
+func newListLFUBench(capacity int) *listLFUBench {
+	return &listLFUBench{c: newListLFUCache(capacity)}
+}
+
+func (b *listLFUBench) Put(key int, value int) {
+	b.c.Put(key, value)
+}
+
+func (b *listLFUBench) Get(key int) (int, bool) {
+	v := b.c.Get(key)
+	return v, v != -1
+}
+
+func BenchmarkLFUGet(b *testing.B) {
+	benchmarkPolicy(b, NewLFUCache[int, int](10000), 10000)
+}
+
+func BenchmarkListLFUGet(b *testing.B) {
+	benchmarkPolicy(b, newListLFUBench(10000), 10000)
+}
+
+func BenchmarkLFUChurn(b *testing.B) {
+	benchmarkChurn(b, NewLFUCache[int, int](10000), 10000)
+}
+
+func BenchmarkListLFUChurn(b *testing.B) {
+	benchmarkChurn(b, newListLFUBench(10000), 10000)
+}
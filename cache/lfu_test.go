@@ -3,11 +3,14 @@ package cache
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"testing"
 )
 
 func TestLFU(t *testing.T) {
-	cache := NewLFUCache(10)
+	cache := NewLFUCache[int, int](10)
 
 	input := [][]int{}
 	inputStr := "[[10,13],[3,17],[6,11],[10,5],[9,10],[13],[2,19],[2],[3],[5,25],[8],[9,22],[5,5],[1,30],[11],[9,12],[7],[5],[8],[9],[4,30],[9,3],[9],[10],[10],[6,14],[3,1],[3],[10,11],[8],[2,14],[1],[5],[4],[11,4],[12,24],[5,18],[13],[7,23],[8],[12],[3,27],[2,12],[5],[2,9],[13,4],[8,18],[1,7],[6],[9,29],[8,21],[5],[6,30],[1,12],[10],[4,15],[7,22],[11,26],[8,17],[9,29],[5],[3,4],[11,30],[12],[4,29],[3],[9],[6],[3,4],[1],[10],[3,29],[10,28],[1,20],[11,13],[3],[3,12],[3,8],[10,9],[3,26],[8],[7],[5],[13,17],[2,27],[11,15],[12],[9,19],[2,15],[3,16],[1],[12,17],[9,1],[6,19],[4],[5],[5],[8,1],[11,7],[5,2],[9,28],[1],[2,2],[7,4],[4,22],[7,24],[9,26],[13,28],[11,26]]"
@@ -22,7 +25,10 @@ func TestLFU(t *testing.T) {
 		if len(data) > 1 {
 			cache.Put(data[0], data[1])
 		} else {
-			v := cache.Get(data[0])
+			v, ok := cache.Get(data[0])
+			if !ok {
+				v = -1
+			}
 			expect, ok := out[i].(float64)
 			if !ok {
 				fmt.Printf("expect is null, k=%d, v=%d", data[0], v)
@@ -35,3 +41,90 @@ func TestLFU(t *testing.T) {
 		}
 	}
 }
+
+func TestLFUOrder(t *testing.T) {
+	cache := NewLFUCache[string, int](3)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Put("c", 3)
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("b")
+	cache.Get("c")
+	// a:3, c:2, b:2，同频率下c比b新
+	if keys := cache.Keys(); !reflect.DeepEqual(keys, []string{"a", "c", "b"}) {
+		t.Errorf("keys expect [a c b], got %v", keys)
+	}
+
+	// 同频率淘汰最久未使用的b
+	cache.Put("d", 4)
+	if cache.Contains("b") {
+		t.Errorf("b should be evicted")
+	}
+	// Peek不更新频率
+	cache.Peek("d")
+	cache.Put("e", 5)
+	if cache.Contains("d") {
+		t.Errorf("d should be evicted")
+	}
+	if keys := cache.Keys(); !reflect.DeepEqual(keys, []string{"a", "c", "e"}) {
+		t.Errorf("keys expect [a c e], got %v", keys)
+	}
+
+	cache.Delete("a")
+	cache.Delete("c")
+	cache.Delete("e")
+	if cache.Len() != 0 || len(cache.Keys()) != 0 {
+		t.Errorf("cache should be empty, len=%d", cache.Len())
+	}
+	cache.Put("f", 6)
+	if v, ok := cache.Get("f"); !ok || v != 6 {
+		t.Errorf("get f expect 6, got %v, %v", v, ok)
+	}
+}
+
+func TestLFUBulk(t *testing.T) {
+	cache := NewLFUCache[int, int](5)
+	cache.PutMany(slices.All([]int{0, 10, 20, 30, 40}))
+	cache.GetMany([]int{3, 3, 1, 9})
+	cache.Get(4)
+
+	var keys []int
+	for k, v := range cache.All() {
+		if v != k*10 {
+			t.Errorf("value of %d expect %d, got %d", k, k*10, v)
+		}
+		keys = append(keys, k)
+	}
+	if want := []int{3, 4, 1, 2, 0}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("all keys expect %v, got %v", want, keys)
+	}
+	// 提前结束遍历
+	n := 0
+	for k := range cache.All() {
+		n++
+		if k == 4 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("break should stop iteration after 2 keys, got %d", n)
+	}
+
+	if n := cache.DeleteFunc(func(k, v int) bool { return k%2 == 0 }); n != 3 {
+		t.Errorf("delete func expect 3, got %d", n)
+	}
+	if want := []int{3, 1}; !reflect.DeepEqual(cache.Keys(), want) {
+		t.Errorf("keys expect %v, got %v", want, cache.Keys())
+	}
+
+	cache.PutMany(maps.All(map[int]int{5: 50, 6: 60}))
+	cache.Resize(2)
+	if want := []int{3, 1}; !reflect.DeepEqual(cache.Keys(), want) {
+		t.Errorf("resize should keep frequent keys %v, got %v", want, cache.Keys())
+	}
+	got := cache.GetMany([]int{1, 3, 5})
+	if want := map[int]int{1: 10, 3: 30}; !maps.Equal(got, want) {
+		t.Errorf("get many expect %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,57 @@
+package cache
+
+// entry 多个淘汰策略共用的链表节点
+type entry[K comparable, V any] struct {
+	pre   *entry[K, V]
+	next  *entry[K, V]
+	key   K
+	value V
+	list  *entryList[K, V] // 节点所在的链表
+	freq  uint8            // 访问标记，SIEVE和S3-FIFO命中时只修改这个字段
+}
+
+// entryList 双向链表，表头为最近使用，表尾为最久未使用
+type entryList[K comparable, V any] struct {
+	head   *entry[K, V]
+	tail   *entry[K, V]
+	length int
+}
+
+// unlink 将节点从链表中摘除
+func (l *entryList[K, V]) unlink(node *entry[K, V]) {
+	if node.pre == nil {
+		l.head = node.next
+	} else {
+		node.pre.next = node.next
+	}
+	if node.next == nil {
+		l.tail = node.pre
+	} else {
+		node.next.pre = node.pre
+	}
+	node.pre = nil
+	node.next = nil
+	node.list = nil
+	l.length--
+}
+
+// pushFront 将节点插入到表头
+func (l *entryList[K, V]) pushFront(node *entry[K, V]) {
+	node.pre = nil
+	node.next = l.head
+	if l.head != nil {
+		l.head.pre = node
+	}
+	l.head = node
+	if l.tail == nil {
+		l.tail = node
+	}
+	node.list = l
+	l.length++
+}
+
+// moveTo 将节点移到目标链表的表头，目标可以是节点当前所在的链表
+func (node *entry[K, V]) moveTo(to *entryList[K, V]) {
+	node.list.unlink(node)
+	to.pushFront(node)
+}
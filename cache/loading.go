@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LoadingCache 自动加载的LRU缓存
+// 原理：Get未命中时调用loader加载数据并写入缓存，同一个key并发未命中时只有一个加载请求，其他请求等待结果(singleflight)
+// 负缓存：loader返回错误时可以把错误缓存一小段时间，避免下游故障时请求全部打到下游
+// 刷新：数据写入超过refreshAfter之后，Get先返回旧值，同时在后台异步刷新(stale-while-revalidate)
+
+// LoaderFunc 加载函数
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// loadingEntry 缓存的加载结果
+type loadingEntry[V any] struct {
+	value    V
+	err      error
+	loadedAt int64 // 加载完成时间，unix纳秒
+}
+
+// loadCall 进行中的加载请求
+type loadCall[V any] struct {
+	done        chan struct{}
+	value       V
+	err         error
+	invalidated bool // 加载期间key被Put或Delete，结果只返回给调用方，不写入缓存
+}
+
+// LoadingCache 结构
+type LoadingCache[K comparable, V any] struct {
+	cache        *LRUCache[K, *loadingEntry[V]]
+	loader       LoaderFunc[K, V]
+	mu           sync.Mutex
+	calls        map[K]*loadCall[V]
+	negativeTTL  time.Duration
+	refreshAfter time.Duration
+	now          func() time.Time
+}
+
+// NewLoadingCache constructor，ttl为加载结果的过期时间，<=0表示不过期
+func NewLoadingCache[K comparable, V any](capacity int, ttl time.Duration, loader LoaderFunc[K, V]) *LoadingCache[K, V] {
+	return &LoadingCache[K, V]{
+		cache:  NewLRUCacheWithTTL[K, *loadingEntry[V]](capacity, ttl),
+		loader: loader,
+		calls:  map[K]*loadCall[V]{},
+		now:    time.Now,
+	}
+}
+
+// SetNegativeTTL 设置加载错误的缓存时间，<=0表示不缓存错误
+func (c *LoadingCache[K, V]) SetNegativeTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.negativeTTL = ttl
+}
+
+// SetRefreshAfterWrite 设置写入多久之后触发异步刷新，<=0表示不刷新
+func (c *LoadingCache[K, V]) SetRefreshAfterWrite(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshAfter = d
+}
+
+// SetClock 替换时钟，方便测试时手动推进时间
+func (c *LoadingCache[K, V]) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.mu.Unlock()
+	c.cache.SetClock(now)
+}
+
+// Get 获取kv，未命中时调用loader加载，ctx只控制当前调用的等待，不会中断共享的加载请求
+func (c *LoadingCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	if e, ok := c.cache.Get(key); ok {
+		if e.err != nil {
+			var zero V
+			return zero, e.err
+		}
+		c.mu.Lock()
+		stale := c.refreshAfter > 0 && c.now().UnixNano()-e.loadedAt >= int64(c.refreshAfter)
+		c.mu.Unlock()
+		if stale {
+			c.load(ctx, key, true)
+		}
+		return e.value, nil
+	}
+
+	call := c.load(ctx, key, false)
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load 发起加载请求，同一个key已经有进行中的请求时直接复用
+// 加载在独立协程中执行，使用不带取消的ctx，避免第一个调用方取消导致其他等待者失败
+func (c *LoadingCache[K, V]) load(ctx context.Context, key K, refresh bool) *loadCall[V] {
+	c.mu.Lock()
+	if call, exist := c.calls[key]; exist {
+		c.mu.Unlock()
+		return call
+	}
+	call := &loadCall[V]{done: make(chan struct{})}
+	c.calls[key] = call
+	negativeTTL := c.negativeTTL
+	now := c.now
+	c.mu.Unlock()
+
+	go func() {
+		call.value, call.err = c.callLoader(context.WithoutCancel(ctx), key)
+		// 写缓存和移除请求在同一把锁内完成：新请求不会在两者之间未命中又发起一次加载，
+		// Delete也不会夹在检查invalidated和写缓存之间，被删除的key不会被旧结果写回来
+		c.mu.Lock()
+		switch {
+		case call.invalidated:
+			// 加载期间key被修改或删除，加载结果已经过时
+		case call.err == nil:
+			c.cache.Put(key, &loadingEntry[V]{value: call.value, loadedAt: now().UnixNano()})
+		case !refresh && negativeTTL > 0:
+			// 刷新失败时保留旧值，下次Get再重试
+			c.cache.PutWithTTL(key, &loadingEntry[V]{err: call.err, loadedAt: now().UnixNano()}, negativeTTL)
+		}
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+// callLoader 调用loader，loader panic时转换为错误返回
+func (c *LoadingCache[K, V]) callLoader(ctx context.Context, key K) (value V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cache: loader panic: %v", r)
+		}
+	}()
+	return c.loader(ctx, key)
+}
+
+// invalidate 作废key上进行中的加载请求，调用方持有锁
+func (c *LoadingCache[K, V]) invalidate(key K) {
+	if call, exist := c.calls[key]; exist {
+		call.invalidated = true
+	}
+}
+
+// Put 手动写入，进行中的加载请求完成后不会覆盖写入的值
+func (c *LoadingCache[K, V]) Put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate(key)
+	c.cache.Put(key, &loadingEntry[V]{value: value, loadedAt: c.now().UnixNano()})
+}
+
+// Delete 删除key，进行中的加载请求完成后不会再写入缓存
+func (c *LoadingCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate(key)
+	c.cache.Delete(key)
+}
+
+// Len 当前存储的key数量，包含缓存的错误
+func (c *LoadingCache[K, V]) Len() int {
+	return c.cache.Len()
+}
+
+// Stats 返回统计快照，命中缓存的错误也计为命中
+func (c *LoadingCache[K, V]) Stats() Stats {
+	return c.cache.Stats()
+}
+
+// OnEvict 设置淘汰回调，缓存的错误被淘汰时不触发
+func (c *LoadingCache[K, V]) OnEvict(fn EvictFunc[K, V]) {
+	if fn == nil {
+		c.cache.OnEvict(nil)
+		return
+	}
+	c.cache.OnEvict(func(key K, e *loadingEntry[V], reason EvictReason) {
+		if e.err == nil {
+			fn(key, e.value, reason)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadingCacheSingleflight(t *testing.T) {
+	var calls int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	cache := NewLoadingCache[int, int](10, 0, func(ctx context.Context, key int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		started <- struct{}{}
+		<-release
+		return key * 10, nil
+	})
+
+	concurrency := 20
+	ready := sync.WaitGroup{}
+	wg := sync.WaitGroup{}
+	ready.Add(concurrency)
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			ready.Done()
+			if v, err := cache.Get(context.Background(), 1); err != nil || v != 10 {
+				t.Errorf("get 1 expect 10, got %v, %v", v, err)
+			}
+		}()
+	}
+	// loader阻塞期间所有请求都已经发出，没赶上这次加载的请求在放行之后直接命中缓存，
+	// 写缓存和移除加载请求在同一把锁内完成，中间不会再发起一次加载
+	ready.Wait()
+	<-started
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("loader should be called once, got %d", n)
+	}
+	if v, err := cache.Get(context.Background(), 1); err != nil || v != 10 || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("second get should hit cache, got %v, %v", v, err)
+	}
+}
+
+func TestLoadingCacheDeleteDuringLoad(t *testing.T) {
+	started := make(chan int, 1)
+	release := make(chan struct{}, 1)
+	cache := NewLoadingCache[int, int](10, 0, func(ctx context.Context, key int) (int, error) {
+		started <- key
+		<-release
+		return key * 10, nil
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// 等待者仍然拿到加载结果
+		if v, err := cache.Get(context.Background(), 1); err != nil || v != 10 {
+			t.Errorf("get 1 expect 10, got %v, %v", v, err)
+		}
+	}()
+	<-started
+	cache.Delete(1)
+	release <- struct{}{}
+	<-done
+	if cache.Len() != 0 {
+		t.Errorf("deleted key should not be written back by in-flight load, len=%d", cache.Len())
+	}
+
+	// 加载期间手动Put的值不会被旧结果覆盖
+	go func() {
+		<-started
+		cache.Put(2, 200)
+		release <- struct{}{}
+	}()
+	if v, err := cache.Get(context.Background(), 2); err != nil || v != 20 {
+		t.Errorf("get 2 expect loaded 20, got %v, %v", v, err)
+	}
+	if v, _ := cache.Get(context.Background(), 2); v != 200 {
+		t.Errorf("put during load expect 200, got %v", v)
+	}
+}
+
+func TestLoadingCacheNegative(t *testing.T) {
+	clock := newFakeClock()
+	errBackend := errors.New("backend down")
+	var calls int32
+	cache := NewLoadingCache[string, string](10, 0, func(ctx context.Context, key string) (string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return "", errBackend
+		}
+		return "v-" + key, nil
+	})
+	cache.SetClock(clock.Now)
+	cache.SetNegativeTTL(time.Second)
+
+	if _, err := cache.Get(context.Background(), "a"); !errors.Is(err, errBackend) {
+		t.Errorf("first get expect backend error, got %v", err)
+	}
+	// 错误被缓存，不会再调用loader
+	if _, err := cache.Get(context.Background(), "a"); !errors.Is(err, errBackend) || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("error should be cached, got %v, calls=%d", err, calls)
+	}
+	clock.Advance(time.Second)
+	if v, err := cache.Get(context.Background(), "a"); err != nil || v != "v-a" {
+		t.Errorf("get after negative ttl expect v-a, got %v, %v", v, err)
+	}
+}
+
+func TestLoadingCacheRefresh(t *testing.T) {
+	clock := newFakeClock()
+	var version int32
+	loaded := make(chan struct{}, 10)
+	cache := NewLoadingCache[string, int32](10, 0, func(ctx context.Context, key string) (int32, error) {
+		defer func() { loaded <- struct{}{} }()
+		return atomic.AddInt32(&version, 1), nil
+	})
+	cache.SetClock(clock.Now)
+	cache.SetRefreshAfterWrite(time.Minute)
+
+	if v, _ := cache.Get(context.Background(), "a"); v != 1 {
+		t.Errorf("get a expect 1, got %v", v)
+	}
+	<-loaded
+	clock.Advance(time.Minute)
+	// 过了刷新时间，先返回旧值，后台刷新
+	if v, _ := cache.Get(context.Background(), "a"); v != 1 {
+		t.Errorf("stale get expect 1, got %v", v)
+	}
+	<-loaded
+	deadline := time.Now().Add(time.Second)
+	for {
+		if v, _ := cache.Get(context.Background(), "a"); v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("value should be refreshed to 2")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoadingCacheContext(t *testing.T) {
+	release := make(chan struct{})
+	cache := NewLoadingCache[int, int](10, 0, func(ctx context.Context, key int) (int, error) {
+		<-release
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return key, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cache.Get(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("get expect deadline exceeded, got %v", err)
+	}
+	// 调用方超时不影响加载，加载完成后写入缓存
+	close(release)
+	if v, err := cache.Get(context.Background(), 1); err != nil || v != 1 {
+		t.Errorf("get 1 expect 1, got %v, %v", v, err)
+	}
+
+	panicky := NewLoadingCache[int, int](10, 0, func(ctx context.Context, key int) (int, error) {
+		panic("boom")
+	})
+	if _, err := panicky.Get(context.Background(), 1); err == nil {
+		t.Errorf("loader panic should be returned as error")
+	}
+}
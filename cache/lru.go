@@ -1,93 +1,464 @@
 package cache
 
-// LruCache 基于内存实现、不带过期时间
-// 原理：map结构按照kv存储数据，双向链表保存数据新鲜度
-// 扩展：支持过期时间可以增加一个双向链表按过期时间存储，
+import (
+	"iter"
+	"sync"
+	"time"
+)
+
+// LruCache 基于内存实现、支持过期时间
+// 原理：map结构按照kv存储数据，双向链表保存数据新鲜度，表头为最近使用，表尾为最久未使用
+// 过期：另外用一个双向链表按过期时间升序串起带过期时间的节点，表头最先过期
+//   - 惰性过期：Get等读操作发现节点过期时直接删除
+//   - 主动过期：可选的后台janitor定时从过期链表头部清理
+// 容量：默认每个key占1个单位，maxCost即为key数量上限；设置Weigher之后按Weigher计算的开销淘汰，直到总开销不超过maxCost
+// 因为janitor在后台协程运行，所有操作都需要加锁，淘汰回调也在持有锁时执行
 
 // LRUChainNode 链表节点
-type LRUChainNode struct {
-	pre   *LRUChainNode
-	next  *LRUChainNode
-	key   int
-	value int
-	ts    int32
+type LRUChainNode[K comparable, V any] struct {
+	pre      *LRUChainNode[K, V]
+	next     *LRUChainNode[K, V]
+	expPre   *LRUChainNode[K, V] // 过期链表前驱
+	expNext  *LRUChainNode[K, V] // 过期链表后继
+	key      K
+	value    V
+	cost     int64
+	expireAt int64 // 过期时间，unix纳秒，0表示不过期
 }
 
 // LRUCache 结构
-type LRUCache struct {
-	capacity int
-	length   int
-	store    map[int]*LRUChainNode
-	head     *LRUChainNode
-	tail     *LRUChainNode
+type LRUCache[K comparable, V any] struct {
+	metrics[K, V]
+	codecs[K, V]
+	mu      sync.Mutex
+	length  int
+	cost    int64 // 当前总开销
+	maxCost int64 // 总开销上限
+	weigher Weigher[K, V]
+	ttl     time.Duration // 默认过期时间，<=0表示不过期
+	store   map[K]*LRUChainNode[K, V]
+	head    *LRUChainNode[K, V]
+	tail    *LRUChainNode[K, V]
+	expHead *LRUChainNode[K, V]
+	expTail *LRUChainNode[K, V]
+	now     func() time.Time
+	stop    chan struct{}
 }
 
 // NewLRUCache constructor
-func NewLRUCache(capacity int) LRUCache {
-	return LRUCache{
-		capacity: capacity,
-		length:   0,
-		store:    map[int]*LRUChainNode{},
-	}
+func NewLRUCache[K comparable, V any](capacity int) *LRUCache[K, V] {
+	return NewLRUCacheWithTTL[K, V](capacity, 0)
 }
 
-// Delete 删除key
-func (c *LRUCache) Delete(key int) {
-	node, exist := c.store[key]
-	if !exist {
-		return
-	}
-	delete(c.store, key)
-	if c.length == 1 {
-		c.head = nil
-		c.tail = nil
-		c.length--
-		return
+// NewLRUCacheWithTTL constructor，Put写入的key默认在ttl之后过期
+func NewLRUCacheWithTTL[K comparable, V any](capacity int, ttl time.Duration) *LRUCache[K, V] {
+	return &LRUCache[K, V]{
+		length:  0,
+		maxCost: int64(capacity),
+		ttl:     ttl,
+		store:   map[K]*LRUChainNode[K, V]{},
+		now:     time.Now,
 	}
+}
+
+// NewLRUCacheWithWeigher constructor，按weigher计算每个kv的开销，总开销不超过maxCost
+func NewLRUCacheWithWeigher[K comparable, V any](maxCost int64, weigher Weigher[K, V]) *LRUCache[K, V] {
+	c := NewLRUCache[K, V](0)
+	c.maxCost = maxCost
+	c.weigher = weigher
+	return c
+}
+
+// SetClock 替换时钟，方便测试时手动推进时间
+func (c *LRUCache[K, V]) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// unlink 将节点从链表中摘除，不处理map
+func (c *LRUCache[K, V]) unlink(node *LRUChainNode[K, V]) {
 	if node.pre == nil {
-		c.head = c.head.next
-		c.head.pre = nil
+		c.head = node.next
 	} else {
 		node.pre.next = node.next
 	}
 	if node.next == nil {
-		c.tail = c.tail.pre
-		c.tail.next = nil
+		c.tail = node.pre
 	} else {
 		node.next.pre = node.pre
 	}
+	node.pre = nil
+	node.next = nil
+}
+
+// pushFront 将节点插入到链表头部
+func (c *LRUCache[K, V]) pushFront(node *LRUChainNode[K, V]) {
+	node.pre = nil
+	node.next = c.head
+	if c.head != nil {
+		c.head.pre = node
+	}
+	c.head = node
+	if c.tail == nil {
+		c.tail = node
+	}
+}
+
+// unlinkExpire 将节点从过期链表中摘除
+func (c *LRUCache[K, V]) unlinkExpire(node *LRUChainNode[K, V]) {
+	if node.expireAt == 0 {
+		return
+	}
+	if node.expPre == nil {
+		c.expHead = node.expNext
+	} else {
+		node.expPre.expNext = node.expNext
+	}
+	if node.expNext == nil {
+		c.expTail = node.expPre
+	} else {
+		node.expNext.expPre = node.expPre
+	}
+	node.expPre = nil
+	node.expNext = nil
+}
+
+// linkExpire 按过期时间插入过期链表，新写入的节点通常最晚过期，所以从表尾往前找位置
+func (c *LRUCache[K, V]) linkExpire(node *LRUChainNode[K, V]) {
+	if node.expireAt == 0 {
+		return
+	}
+	pre := c.expTail
+	for pre != nil && pre.expireAt > node.expireAt {
+		pre = pre.expPre
+	}
+	node.expPre = pre
+	if pre == nil {
+		node.expNext = c.expHead
+		c.expHead = node
+	} else {
+		node.expNext = pre.expNext
+		pre.expNext = node
+	}
+	if node.expNext == nil {
+		c.expTail = node
+	} else {
+		node.expNext.expPre = node
+	}
+}
+
+// expired 判断节点是否已经过期
+func (c *LRUCache[K, V]) expired(node *LRUChainNode[K, V], now int64) bool {
+	return node.expireAt != 0 && node.expireAt <= now
+}
+
+// remove 删除节点
+func (c *LRUCache[K, V]) remove(node *LRUChainNode[K, V], reason EvictReason) {
+	delete(c.store, node.key)
+	c.unlink(node)
+	c.unlinkExpire(node)
 	c.length--
+	c.cost -= node.cost
+	c.evicted(node.key, node.value, reason)
 }
 
-// Get 获取kv
-func (c *LRUCache) Get(key int) int {
+// lookup 查找未过期的节点，过期的节点顺带删除
+func (c *LRUCache[K, V]) lookup(key K) *LRUChainNode[K, V] {
 	node, exist := c.store[key]
 	if !exist {
-		return -1
+		return nil
+	}
+	if c.expired(node, c.now().UnixNano()) {
+		c.remove(node, EvictExpire)
+		return nil
 	}
-	c.Delete(key)
-	c.Put(node.key, node.value)
-	return node.value
+	return node
 }
 
-// Put 插入
-func (c *LRUCache) Put(key int, value int) {
-	c.Delete(key)
-	if c.length+1 > c.capacity {
-		c.Delete(c.tail.key)
+// Delete 删除key
+func (c *LRUCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if node, exist := c.store[key]; exist {
+		c.remove(node, EvictDelete)
 	}
-	node := LRUChainNode{key: key, value: value}
-	if c.length == 0 {
-		c.head = &node
-		c.tail = &node
-		c.store[key] = &node
-		c.length++
+}
+
+// Get 获取kv，命中时将节点移到表头
+func (c *LRUCache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+// get Get的实现，调用方持有锁
+func (c *LRUCache[K, V]) get(key K) (value V, ok bool) {
+	node := c.lookup(key)
+	if node == nil {
+		c.miss()
 		return
 	}
-	// 头部处理
-	c.head.pre = &node
-	node.next = c.head
-	c.head = &node
-	c.store[key] = &node
+	c.hit()
+	if node != c.head {
+		c.unlink(node)
+		c.pushFront(node)
+	}
+	return node.value, true
+}
+
+// Peek 获取kv，不刷新新鲜度
+func (c *LRUCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	node := c.lookup(key)
+	if node == nil {
+		return
+	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不刷新新鲜度
+func (c *LRUCache[K, V]) Contains(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookup(key) != nil
+}
+
+// Put 插入，使用默认过期时间
+func (c *LRUCache[K, V]) Put(key K, value V) {
+	c.PutWithTTL(key, value, c.ttl)
+}
+
+// PutWithTTL 插入并指定过期时间，ttl<=0表示不过期
+// 已存在则更新值和过期时间并移到表头，超过容量时优先淘汰已过期的节点，其次淘汰表尾
+// 开销超过maxCost的kv直接拒绝，已存在的旧值也会被删除
+func (c *LRUCache[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putWithTTL(key, value, ttl)
+}
+
+// putWithTTL PutWithTTL的实现，调用方持有锁
+func (c *LRUCache[K, V]) putWithTTL(key K, value V, ttl time.Duration) {
+	if c.maxCost <= 0 {
+		return
+	}
+	c.put()
+	cost := c.weigh(key, value)
+	if cost > c.maxCost {
+		if node, exist := c.store[key]; exist {
+			c.remove(node, EvictReplace)
+		}
+		return
+	}
+	now := c.now().UnixNano()
+	var expireAt int64
+	if ttl > 0 {
+		expireAt = now + int64(ttl)
+	}
+	if node, exist := c.store[key]; exist {
+		old := node.value
+		node.value = value
+		c.cost += cost - node.cost
+		node.cost = cost
+		c.unlinkExpire(node)
+		node.expireAt = expireAt
+		c.linkExpire(node)
+		if node != c.head {
+			c.unlink(node)
+			c.pushFront(node)
+		}
+		c.evicted(key, old, EvictReplace)
+		// 新值开销变大，淘汰表尾，节点已经在表头，不会淘汰到自己
+		c.evictOver(0, now)
+		return
+	}
+	c.evictOver(cost, now)
+	node := &LRUChainNode[K, V]{key: key, value: value, cost: cost, expireAt: expireAt}
+	c.pushFront(node)
+	c.linkExpire(node)
+	c.store[key] = node
 	c.length++
+	c.cost += cost
+}
+
+// weigh 计算kv的开销，未设置weigher时每个kv开销为1
+func (c *LRUCache[K, V]) weigh(key K, value V) int64 {
+	if c.weigher == nil {
+		return 1
+	}
+	return max(c.weigher(key, value), 0)
+}
+
+// evictOver 淘汰节点直到能再放下cost的开销，优先淘汰已过期的节点
+func (c *LRUCache[K, V]) evictOver(cost int64, now int64) {
+	for c.tail != nil && c.cost+cost > c.maxCost {
+		if c.expHead != nil && c.expired(c.expHead, now) {
+			c.remove(c.expHead, EvictExpire)
+		} else {
+			c.remove(c.tail, EvictCapacity)
+		}
+	}
+}
+
+// RemoveExpired 从过期链表头部清理所有已过期的节点，返回清理数量
+func (c *LRUCache[K, V]) RemoveExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now().UnixNano()
+	n := 0
+	for c.expHead != nil && c.expired(c.expHead, now) {
+		c.remove(c.expHead, EvictExpire)
+		n++
+	}
+	return n
+}
+
+// StartJanitor 启动后台协程，每隔interval主动清理一次过期节点，重复调用会先停掉之前的janitor
+// interval<=0时什么都不做，已经在运行的janitor也不受影响
+func (c *LRUCache[K, V]) StartJanitor(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	c.mu.Lock()
+	if c.stop != nil {
+		close(c.stop)
+	}
+	c.stop = stop
+	c.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.RemoveExpired()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopJanitor 停止后台清理
+func (c *LRUCache[K, V]) StopJanitor() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+// Stats 返回统计快照
+func (c *LRUCache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// OnEvict 设置淘汰回调，回调在持有锁时执行，不能再调用同一个缓存的方法
+func (c *LRUCache[K, V]) OnEvict(fn EvictFunc[K, V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = fn
+}
+
+// Cost 当前总开销，未设置weigher时等于key数量
+func (c *LRUCache[K, V]) Cost() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cost
+}
+
+// Len 当前存储的key数量，可能包含已过期但还未被清理的key
+func (c *LRUCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.length
+}
+
+// Keys 按新鲜度从新到旧返回所有未过期的key
+func (c *LRUCache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now().UnixNano()
+	keys := make([]K, 0, c.length)
+	for node := c.head; node != nil; node = node.next {
+		if !c.expired(node, now) {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
+
+// All 按新鲜度从新到旧遍历所有未过期的kv，不刷新新鲜度
+// 遍历的是调用时刻的快照，遍历过程中可以调用缓存的其他方法
+func (c *LRUCache[K, V]) All() iter.Seq2[K, V] {
+	c.mu.Lock()
+	now := c.now().UnixNano()
+	keys := make([]K, 0, c.length)
+	values := make([]V, 0, c.length)
+	for node := c.head; node != nil; node = node.next {
+		if !c.expired(node, now) {
+			keys = append(keys, node.key)
+			values = append(values, node.value)
+		}
+	}
+	c.mu.Unlock()
+	return func(yield func(K, V) bool) {
+		for i, key := range keys {
+			if !yield(key, values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// GetMany 批量获取，只加一次锁，返回命中的kv，效果等同于依次调用Get
+func (c *LRUCache[K, V]) GetMany(keys []K) map[K]V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if value, ok := c.get(key); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// PutMany 按顺序批量插入，只加一次锁，使用默认过期时间，kvs中不能再调用同一个缓存的方法
+func (c *LRUCache[K, V]) PutMany(kvs iter.Seq2[K, V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range kvs {
+		c.putWithTTL(key, value, c.ttl)
+	}
+}
+
+// DeleteFunc 删除所有满足条件的kv，返回删除数量，pred在持有锁时执行
+func (c *LRUCache[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for node := c.head; node != nil; {
+		next := node.next
+		if pred(node.key, node.value) {
+			c.remove(node, EvictDelete)
+			n++
+		}
+		node = next
+	}
+	return n
+}
+
+// Resize 调整容量，未设置weigher时为key数量上限，否则为总开销上限，容量变小时立即淘汰到新容量以内
+func (c *LRUCache[K, V]) Resize(capacity int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxCost = max(capacity, 0)
+	c.evictOver(0, c.now().UnixNano())
 }
@@ -2,28 +2,270 @@ package cache
 
 import (
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestLru(t *testing.T) {
-	cache := NewLRUCache(1)
+	cache := NewLRUCache[int, int](1)
 	cache.Put(1, 11)
 	cache.Put(2, 22)
 	cache.Put(3, 33)
 	fmt.Println("put success")
 
-	v := cache.Get(2)
-	fmt.Printf("get (2) return %v\n", v) // 返回  22
+	v, ok := cache.Get(2)
+	fmt.Printf("get (2) return %v, %v\n", v, ok) // 返回 0, false (已被3淘汰)
+	if ok {
+		t.Errorf("get (2) expect miss, got %v", v)
+	}
 
-	v = cache.Get(2)
-	fmt.Printf("get (2) return %v\n", v) // 返回  22
+	v, ok = cache.Get(3)
+	fmt.Printf("get (3) return %v, %v\n", v, ok) // 返回 33, true
+	if !ok || v != 33 {
+		t.Errorf("get (3) expect 33, got %v, %v", v, ok)
+	}
 
-	cache.Put(4, 44) // 该操作会使(2,22) 作废
+	cache.Put(4, 44) // 该操作会使(3,33) 作废
 	fmt.Println("put 4 success")
 
-	v = cache.Get(2)
-	fmt.Printf("get (2) return %v \n", v) // 返回 -1 (未找到)
+	v, ok = cache.Get(3)
+	fmt.Printf("get (3) return %v, %v\n", v, ok) // 返回 0, false (未找到)
+	if ok {
+		t.Errorf("get (3) expect miss, got %v", v)
+	}
 
-	v = cache.Get(3)
-	fmt.Printf("get (3) return %v\n", v) // 返回  33
+	v, ok = cache.Get(4)
+	fmt.Printf("get (4) return %v, %v\n", v, ok) // 返回 44, true
+	if !ok || v != 44 {
+		t.Errorf("get (4) expect 44, got %v, %v", v, ok)
+	}
+}
+
+func TestLruOrder(t *testing.T) {
+	cache := NewLRUCache[string, int](3)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Put("c", 3)
+	if keys := cache.Keys(); !reflect.DeepEqual(keys, []string{"c", "b", "a"}) {
+		t.Errorf("keys expect [c b a], got %v", keys)
+	}
+
+	// Get 刷新新鲜度，Peek 不刷新
+	cache.Get("a")
+	cache.Peek("b")
+	if keys := cache.Keys(); !reflect.DeepEqual(keys, []string{"a", "c", "b"}) {
+		t.Errorf("keys expect [a c b], got %v", keys)
+	}
+
+	// 覆盖写刷新新鲜度，不占用容量
+	cache.Put("b", 20)
+	if v, _ := cache.Peek("b"); v != 20 || cache.Len() != 3 {
+		t.Errorf("overwrite b expect 20 with len 3, got %v with len %d", v, cache.Len())
+	}
+
+	cache.Put("d", 4) // 淘汰c
+	if cache.Contains("c") {
+		t.Errorf("c should be evicted")
+	}
+	if keys := cache.Keys(); !reflect.DeepEqual(keys, []string{"d", "b", "a"}) {
+		t.Errorf("keys expect [d b a], got %v", keys)
+	}
+
+	cache.Delete("b")
+	cache.Delete("x")
+	if cache.Contains("b") || cache.Len() != 2 {
+		t.Errorf("delete b failed, len=%d", cache.Len())
+	}
+	cache.Delete("d")
+	cache.Delete("a")
+	if cache.Len() != 0 || len(cache.Keys()) != 0 {
+		t.Errorf("cache should be empty, len=%d", cache.Len())
+	}
+	cache.Put("e", 5)
+	if v, ok := cache.Get("e"); !ok || v != 5 {
+		t.Errorf("get e expect 5, got %v, %v", v, ok)
+	}
+}
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1600000000, 0)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestLruTTL(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewLRUCacheWithTTL[string, int](10, time.Minute)
+	cache.SetClock(clock.Now)
+
+	cache.Put("a", 1)                        // 默认1分钟过期
+	cache.PutWithTTL("b", 2, 10*time.Second) // 10秒过期
+	cache.PutWithTTL("c", 3, 0)              // 不过期
+
+	clock.Advance(10 * time.Second)
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("b should be expired")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expired b should be removed lazily, len=%d", cache.Len())
+	}
+	if v, ok := cache.Get("a"); !ok || v != 1 {
+		t.Errorf("get a expect 1, got %v, %v", v, ok)
+	}
+
+	// 覆盖写刷新过期时间
+	clock.Advance(50 * time.Second)
+	cache.Put("a", 10)
+	clock.Advance(30 * time.Second)
+	if v, ok := cache.Get("a"); !ok || v != 10 {
+		t.Errorf("get a expect 10, got %v, %v", v, ok)
+	}
+	clock.Advance(30 * time.Second)
+	if cache.Contains("a") {
+		t.Errorf("a should be expired")
+	}
+	if v, ok := cache.Get("c"); !ok || v != 3 {
+		t.Errorf("get c expect 3, got %v, %v", v, ok)
+	}
+}
+
+func TestLruTTLEvictExpiredFirst(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewLRUCache[int, int](3)
+	cache.SetClock(clock.Now)
+	cache.Put(1, 1)
+	cache.PutWithTTL(2, 2, time.Second)
+	cache.Put(3, 3)
+	cache.Get(1)
+	clock.Advance(time.Second)
+
+	// 容量已满，优先淘汰已过期的2，而不是最久未使用的1
+	cache.Put(4, 4)
+	if keys := cache.Keys(); !reflect.DeepEqual(keys, []int{4, 1, 3}) {
+		t.Errorf("keys expect [4 1 3], got %v", keys)
+	}
+}
+
+func TestLruRemoveExpired(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewLRUCache[int, int](100)
+	cache.SetClock(clock.Now)
+	// 乱序的过期时间，过期链表需要保持有序
+	for i := 0; i < 50; i++ {
+		cache.PutWithTTL(i, i, time.Duration(50-i)*time.Second)
+	}
+	for i := 50; i < 60; i++ {
+		cache.Put(i, i)
+	}
+	clock.Advance(20 * time.Second)
+	if n := cache.RemoveExpired(); n != 20 {
+		t.Errorf("remove expired expect 20, got %d", n)
+	}
+	if cache.Len() != 40 {
+		t.Errorf("len expect 40, got %d", cache.Len())
+	}
+	for i := 0; i < 60; i++ {
+		if expired := i >= 30 && i < 50; cache.Contains(i) == expired {
+			t.Errorf("key %d expired expect %v", i, expired)
+		}
+	}
+}
+
+func TestLruJanitor(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewLRUCacheWithTTL[int, int](10, time.Second)
+	cache.SetClock(clock.Now)
+	for i := 0; i < 10; i++ {
+		cache.Put(i, i)
+	}
+	cache.StartJanitor(time.Millisecond)
+	defer cache.StopJanitor()
+	clock.Advance(time.Second)
+
+	deadline := time.Now().Add(time.Second)
+	for cache.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("janitor should remove all expired keys, len=%d", cache.Len())
+	}
+}
+
+func TestLruJanitorInvalidInterval(t *testing.T) {
+	cache := NewLRUCacheWithTTL[int, int](10, time.Second)
+	cache.StartJanitor(0)
+	cache.StartJanitor(-time.Second)
+	cache.mu.Lock()
+	started := cache.stop != nil
+	cache.mu.Unlock()
+	if started {
+		t.Errorf("non-positive interval should not start janitor")
+	}
+	cache.StopJanitor()
+}
+
+func TestLruBulk(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	cache := NewLRUCache[int, int](5)
+	cache.SetClock(clock.Now)
+	cache.PutMany(slices.All([]int{0, 10, 20, 30}))
+	cache.PutWithTTL(4, 40, time.Second)
+	cache.Get(1)
+	clock.Advance(2 * time.Second)
+
+	var keys, values []int
+	for k, v := range cache.All() {
+		keys = append(keys, k)
+		values = append(values, v)
+		cache.Get(k) // 遍历的是快照，可以调用其他方法
+	}
+	if want := []int{1, 3, 2, 0}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("all keys expect %v, got %v", want, keys)
+	}
+	if want := []int{10, 30, 20, 0}; !reflect.DeepEqual(values, want) {
+		t.Errorf("all values expect %v, got %v", want, values)
+	}
+
+	got := cache.GetMany([]int{0, 2, 4, 9})
+	if want := map[int]int{0: 0, 2: 20}; !maps.Equal(got, want) {
+		t.Errorf("get many expect %v, got %v", want, got)
+	}
+
+	if n := cache.DeleteFunc(func(k, v int) bool { return v >= 20 }); n != 2 {
+		t.Errorf("delete func expect 2, got %d", n)
+	}
+	if want := []int{0, 1}; !reflect.DeepEqual(cache.Keys(), want) {
+		t.Errorf("keys expect %v, got %v", want, cache.Keys())
+	}
+
+	cache.PutMany(maps.All(map[int]int{5: 5, 6: 6, 7: 7}))
+	cache.Resize(2)
+	if cache.Len() != 2 || cache.Contains(0) || cache.Contains(1) {
+		t.Errorf("resize should evict least recent keys, got %v", cache.Keys())
+	}
+	cache.Resize(3)
+	cache.Put(8, 8)
+	if cache.Len() != 3 {
+		t.Errorf("len expect 3 after grow, got %d", cache.Len())
+	}
 }
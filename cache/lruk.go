@@ -0,0 +1,216 @@
+package cache
+
+import "container/heap"
+
+// LRUKCache LRU-K缓存
+// 原理：记录每个key最近K次的访问时间，淘汰倒数第K次访问最早的key(backward K-distance最大)
+// 访问不足K次的key距离视为无穷大，优先淘汰，它们之间按最近一次访问时间做LRU
+// 被淘汰的key的访问历史保留在一个有容量上限的历史表中，再次写入时恢复，避免刚被淘汰的热点key重新从零开始计数
+// 用小根堆按(倒数第K次访问时间, 最近一次访问时间)维护淘汰顺序，访问和淘汰都是O(logN)
+// 只有Get命中和Put计为一次访问
+// ref: https://www.cs.cmu.edu/~christos/courses/721-resources/p297-o_neil.pdf
+
+// lrukNode 缓存节点
+type lrukNode[K comparable, V any] struct {
+	key   K
+	value V
+	times []uint64 // 最近K次访问时间，从旧到新
+	index int      // 在堆中的下标
+}
+
+// kth 倒数第K次访问时间，不足K次时返回0
+func (n *lrukNode[K, V]) kth(k int) uint64 {
+	if len(n.times) < k {
+		return 0
+	}
+	return n.times[0]
+}
+
+// lrukHeap 按淘汰顺序排列的小根堆，实现heap.Interface
+type lrukHeap[K comparable, V any] struct {
+	k     int
+	nodes []*lrukNode[K, V]
+}
+
+func (h *lrukHeap[K, V]) Len() int {
+	return len(h.nodes)
+}
+
+func (h *lrukHeap[K, V]) Less(i, j int) bool {
+	a, b := h.nodes[i], h.nodes[j]
+	if ka, kb := a.kth(h.k), b.kth(h.k); ka != kb {
+		return ka < kb
+	}
+	return a.times[len(a.times)-1] < b.times[len(b.times)-1]
+}
+
+func (h *lrukHeap[K, V]) Swap(i, j int) {
+	h.nodes[i], h.nodes[j] = h.nodes[j], h.nodes[i]
+	h.nodes[i].index = i
+	h.nodes[j].index = j
+}
+
+func (h *lrukHeap[K, V]) Push(x any) {
+	node := x.(*lrukNode[K, V])
+	node.index = len(h.nodes)
+	h.nodes = append(h.nodes, node)
+}
+
+func (h *lrukHeap[K, V]) Pop() any {
+	n := len(h.nodes)
+	node := h.nodes[n-1]
+	h.nodes[n-1] = nil
+	h.nodes = h.nodes[:n-1]
+	node.index = -1
+	return node
+}
+
+// LRUKCache 结构
+type LRUKCache[K comparable, V any] struct {
+	metrics[K, V]
+	capacity    int
+	k           int
+	historySize int
+	clock       uint64 // 逻辑时钟，每次访问+1
+	store       map[K]*lrukNode[K, V]
+	heap        lrukHeap[K, V]
+	history     map[K]*entry[K, []uint64] // 已淘汰key的访问历史
+	historyList entryList[K, []uint64]    // 历史记录的LRU顺序
+}
+
+// NewLRUKCache constructor，k为统计的访问次数，historySize为保留访问历史的已淘汰key数量上限
+func NewLRUKCache[K comparable, V any](capacity int, k int, historySize int) *LRUKCache[K, V] {
+	if k < 1 {
+		k = 2
+	}
+	return &LRUKCache[K, V]{
+		capacity:    capacity,
+		k:           k,
+		historySize: historySize,
+		store:       map[K]*lrukNode[K, V]{},
+		heap:        lrukHeap[K, V]{k: k},
+		history:     map[K]*entry[K, []uint64]{},
+	}
+}
+
+// access 记录一次访问
+func (c *LRUKCache[K, V]) access(node *lrukNode[K, V]) {
+	c.clock++
+	if len(node.times) == c.k {
+		copy(node.times, node.times[1:])
+		node.times[c.k-1] = c.clock
+	} else {
+		node.times = append(node.times, c.clock)
+	}
+}
+
+// remember 保存已淘汰key的访问历史，超过上限时丢弃最久的历史
+func (c *LRUKCache[K, V]) remember(key K, times []uint64) {
+	if c.historySize <= 0 {
+		return
+	}
+	if c.historyList.length >= c.historySize {
+		old := c.historyList.tail
+		c.historyList.unlink(old)
+		delete(c.history, old.key)
+	}
+	e := &entry[K, []uint64]{key: key, value: times}
+	c.historyList.pushFront(e)
+	c.history[key] = e
+}
+
+// recall 取出并删除key的访问历史
+func (c *LRUKCache[K, V]) recall(key K) []uint64 {
+	e, exist := c.history[key]
+	if !exist {
+		return nil
+	}
+	c.historyList.unlink(e)
+	delete(c.history, key)
+	return e.value
+}
+
+// remove 删除节点
+func (c *LRUKCache[K, V]) remove(node *lrukNode[K, V], reason EvictReason) {
+	heap.Remove(&c.heap, node.index)
+	delete(c.store, node.key)
+	c.evicted(node.key, node.value, reason)
+}
+
+// Delete 删除key，同时丢弃它的访问历史
+func (c *LRUKCache[K, V]) Delete(key K) {
+	if node, exist := c.store[key]; exist {
+		c.remove(node, EvictDelete)
+	}
+	c.recall(key)
+}
+
+// Get 获取kv，命中时记录一次访问
+func (c *LRUKCache[K, V]) Get(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		c.miss()
+		return
+	}
+	c.hit()
+	c.access(node)
+	heap.Fix(&c.heap, node.index)
+	return node.value, true
+}
+
+// Peek 获取kv，不记录访问
+func (c *LRUKCache[K, V]) Peek(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		return
+	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不记录访问
+func (c *LRUKCache[K, V]) Contains(key K) bool {
+	_, exist := c.store[key]
+	return exist
+}
+
+// Put 插入并记录一次访问，超过容量时淘汰堆顶
+func (c *LRUKCache[K, V]) Put(key K, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.put()
+	if node, exist := c.store[key]; exist {
+		old := node.value
+		node.value = value
+		c.access(node)
+		heap.Fix(&c.heap, node.index)
+		c.evicted(key, old, EvictReplace)
+		return
+	}
+	if len(c.store) >= c.capacity {
+		victim := c.heap.nodes[0]
+		c.remove(victim, EvictCapacity)
+		c.remember(victim.key, victim.times)
+	}
+	node := &lrukNode[K, V]{key: key, value: value, times: c.recall(key)}
+	if node.times == nil {
+		node.times = make([]uint64, 0, c.k)
+	}
+	c.access(node)
+	heap.Push(&c.heap, node)
+	c.store[key] = node
+}
+
+// Len 当前存储的key数量
+func (c *LRUKCache[K, V]) Len() int {
+	return len(c.store)
+}
+
+// Keys 返回所有key，没有特定顺序
+func (c *LRUKCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.store))
+	for _, node := range c.heap.nodes {
+		keys = append(keys, node.key)
+	}
+	return keys
+}
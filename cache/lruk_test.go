@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLRUKEvictOrder(t *testing.T) {
+	cache := NewLRUKCache[int, int](3, 2, 3)
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	cache.Put(3, 3)
+	cache.Get(1)
+	cache.Get(2)
+	// 3只访问过一次，距离无穷大，最先淘汰
+	cache.Put(4, 4)
+	if cache.Contains(3) {
+		t.Errorf("3 should be evicted")
+	}
+	// 4也只访问过一次
+	cache.Put(5, 5)
+	if cache.Contains(4) {
+		t.Errorf("4 should be evicted")
+	}
+	cache.Get(5)
+	// 1、2、5都访问过两次，1的倒数第2次访问最早
+	cache.Put(6, 6)
+	if cache.Contains(1) {
+		t.Errorf("1 should be evicted, keys %v", cache.Keys())
+	}
+	keys := cache.Keys()
+	sort.Ints(keys)
+	if len(keys) != 3 || keys[0] != 2 || keys[1] != 5 || keys[2] != 6 {
+		t.Errorf("keys expect [2 5 6], got %v", keys)
+	}
+}
+
+func TestLRUKHistory(t *testing.T) {
+	cache := NewLRUKCache[int, int](2, 2, 2)
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	// 1、2都只访问过一次，最近一次访问更早的1被淘汰，访问历史保留
+	cache.Put(3, 3)
+	if cache.Contains(1) {
+		t.Errorf("1 should be evicted first, keys %v", cache.Keys())
+	}
+	// 1重新写入时带着之前的访问记录，已经满足K次，不会被只访问一次的3淘汰掉
+	cache.Put(1, 1)
+	cache.Put(4, 4)
+	if !cache.Contains(1) {
+		t.Errorf("1 with history should survive, keys %v", cache.Keys())
+	}
+	// 删除同时丢弃访问历史
+	cache.Delete(1)
+	cache.Put(1, 1)
+	cache.Get(4)
+	cache.Put(5, 5)
+	if cache.Contains(1) {
+		t.Errorf("1 without history should be evicted, keys %v", cache.Keys())
+	}
+}
+
+func TestLRUKStatsAndEvict(t *testing.T) {
+	cache := NewLRUKCache[int, int](2, 2, 2)
+	var records []evictRecord
+	cache.OnEvict(func(key int, value int, reason EvictReason) {
+		records = append(records, evictRecord{key, value, reason})
+	})
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	cache.Get(1)
+	cache.Put(3, 3) // 淘汰只访问过一次的2，访问历史保留
+	cache.Put(2, 20)
+	cache.Put(1, 10)
+	cache.Delete(1)
+	cache.Get(1)
+	// 2带着历史重新写入，3只访问过一次被淘汰
+	want := []evictRecord{{2, 2, EvictCapacity}, {3, 3, EvictCapacity}, {1, 1, EvictReplace}, {1, 10, EvictDelete}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records expect %v, got %v", want, records)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Puts != 5 || stats.Evictions != 2 {
+		t.Errorf("stats expect 1 hit, 1 miss, 5 puts, 2 evictions, got %+v", stats)
+	}
+}
@@ -0,0 +1,30 @@
+package cache
+
+import "testing"
+
+// policyBenchCases 对比命中路径开销的各个淘汰策略
+var policyBenchCases = []struct {
+	name     string
+	newCache func(capacity int) benchCache
+}{
+	{"LRU", func(capacity int) benchCache { return NewLRUCache[int, int](capacity) }},
+	{"Clock", func(capacity int) benchCache { return NewClockCache[int, int](capacity) }},
+	{"SIEVE", func(capacity int) benchCache { return NewSIEVECache[int, int](capacity) }},
+	{"S3FIFO", func(capacity int) benchCache { return NewS3FIFOCache[int, int](capacity) }},
+}
+
+func BenchmarkPolicyGet(b *testing.B) {
+	for _, bc := range policyBenchCases {
+		b.Run(bc.name, func(b *testing.B) {
+			benchmarkPolicy(b, bc.newCache(10000), 10000)
+		})
+	}
+}
+
+func BenchmarkPolicyChurn(b *testing.B) {
+	for _, bc := range policyBenchCases {
+		b.Run(bc.name, func(b *testing.B) {
+			benchmarkChurn(b, bc.newCache(10000), 10000)
+		})
+	}
+}
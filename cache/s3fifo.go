@@ -0,0 +1,165 @@
+package cache
+
+// S3FIFOCache S3-FIFO缓存
+// 原理：由三个FIFO队列组成，小队列S占10%容量，主队列M占90%，幽灵队列G只记录从S淘汰的key，长度和M相同
+// 新key进入S，如果key在G中则直接进入M；命中时只把访问计数加1，最大为3，不移动节点
+// S满时淘汰S表尾：访问计数大于1的key晋升到M，否则淘汰并记入G，大多数只访问一次的key在S中就被淘汰
+// 淘汰M表尾时，访问计数大于0的key计数减1后重新插入M表头，否则淘汰
+// ref: https://dl.acm.org/doi/10.1145/3600006.3613147
+
+// S3FIFOCache 结构
+type S3FIFOCache[K comparable, V any] struct {
+	metrics[K, V]
+	capacity  int
+	smallCap  int
+	store     map[K]*entry[K, V]
+	small     entryList[K, V]
+	main      entryList[K, V]
+	ghost     map[K]*entry[K, struct{}]
+	ghostList entryList[K, struct{}]
+}
+
+// NewS3FIFOCache constructor
+func NewS3FIFOCache[K comparable, V any](capacity int) *S3FIFOCache[K, V] {
+	return &S3FIFOCache[K, V]{
+		capacity: capacity,
+		smallCap: max(capacity/10, 1),
+		store:    map[K]*entry[K, V]{},
+		ghost:    map[K]*entry[K, struct{}]{},
+	}
+}
+
+// remember 把从S淘汰的key记入G，G超过M的容量时丢弃最旧的key
+func (c *S3FIFOCache[K, V]) remember(key K) {
+	ghostCap := c.capacity - c.smallCap
+	if ghostCap <= 0 {
+		return
+	}
+	if c.ghostList.length >= ghostCap {
+		old := c.ghostList.tail
+		c.ghostList.unlink(old)
+		delete(c.ghost, old.key)
+	}
+	node := &entry[K, struct{}]{key: key}
+	c.ghostList.pushFront(node)
+	c.ghost[key] = node
+}
+
+// recall 判断key是否在G中，在则从G中删除
+func (c *S3FIFOCache[K, V]) recall(key K) bool {
+	node, exist := c.ghost[key]
+	if !exist {
+		return false
+	}
+	c.ghostList.unlink(node)
+	delete(c.ghost, key)
+	return true
+}
+
+// remove 删除节点
+func (c *S3FIFOCache[K, V]) remove(node *entry[K, V], reason EvictReason) {
+	node.list.unlink(node)
+	delete(c.store, node.key)
+	c.evicted(node.key, node.value, reason)
+}
+
+// evict 淘汰一个key
+func (c *S3FIFOCache[K, V]) evict() {
+	for {
+		if c.small.length > 0 && (c.small.length >= c.smallCap || c.main.length == 0) {
+			node := c.small.tail
+			if node.freq > 1 {
+				node.freq = 0
+				node.moveTo(&c.main)
+				continue
+			}
+			c.remove(node, EvictCapacity)
+			c.remember(node.key)
+			return
+		}
+		node := c.main.tail
+		if node.freq > 0 {
+			node.freq--
+			node.moveTo(&c.main)
+			continue
+		}
+		c.remove(node, EvictCapacity)
+		return
+	}
+}
+
+// Delete 删除key
+func (c *S3FIFOCache[K, V]) Delete(key K) {
+	if node, exist := c.store[key]; exist {
+		c.remove(node, EvictDelete)
+	}
+	c.recall(key)
+}
+
+// Get 获取kv，命中时访问计数加1
+func (c *S3FIFOCache[K, V]) Get(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		c.miss()
+		return
+	}
+	c.hit()
+	node.freq = min(node.freq+1, 3)
+	return node.value, true
+}
+
+// Peek 获取kv，不修改访问计数
+func (c *S3FIFOCache[K, V]) Peek(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		return
+	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不修改访问计数
+func (c *S3FIFOCache[K, V]) Contains(key K) bool {
+	_, exist := c.store[key]
+	return exist
+}
+
+// Put 插入，已存在的key视为一次命中，新key进入S，在G中的key直接进入M
+func (c *S3FIFOCache[K, V]) Put(key K, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.put()
+	if node, exist := c.store[key]; exist {
+		old := node.value
+		node.value = value
+		node.freq = min(node.freq+1, 3)
+		c.evicted(key, old, EvictReplace)
+		return
+	}
+	if len(c.store) >= c.capacity {
+		c.evict()
+	}
+	node := &entry[K, V]{key: key, value: value}
+	if c.recall(key) {
+		c.main.pushFront(node)
+	} else {
+		c.small.pushFront(node)
+	}
+	c.store[key] = node
+}
+
+// Len 当前存储的key数量
+func (c *S3FIFOCache[K, V]) Len() int {
+	return len(c.store)
+}
+
+// Keys 返回所有key，先M后S，队列内按进入顺序从新到旧
+func (c *S3FIFOCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.store))
+	for _, l := range []*entryList[K, V]{&c.main, &c.small} {
+		for node := l.head; node != nil; node = node.next {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
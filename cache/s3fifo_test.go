@@ -0,0 +1,45 @@
+package cache
+
+import "testing"
+
+func TestS3FIFOScanResistant(t *testing.T) {
+	cache := NewS3FIFOCache[int, int](100)
+	// 热点key访问多次，从S淘汰时晋升到M
+	for i := 0; i < 50; i++ {
+		cache.Put(i, i)
+		cache.Get(i)
+		cache.Get(i)
+	}
+	// 只访问一次的扫描key在S中就被淘汰
+	for i := 1000; i < 10000; i++ {
+		cache.Put(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		if v, ok := cache.Get(i); !ok || v != i {
+			t.Errorf("hot key %d should survive scan, got %v, %v", i, v, ok)
+		}
+	}
+	if cache.Len() != 100 {
+		t.Errorf("len expect 100, got %d", cache.Len())
+	}
+}
+
+func TestS3FIFOGhost(t *testing.T) {
+	cache := NewS3FIFOCache[int, int](10)
+	for i := 0; i < 11; i++ {
+		cache.Put(i, i)
+	}
+	// 0只访问一次，从S淘汰后进入G
+	if cache.Contains(0) {
+		t.Fatalf("0 should be evicted")
+	}
+	// 再次写入时直接进入M
+	cache.Put(0, 0)
+	if cache.main.head == nil || cache.main.head.key != 0 {
+		t.Errorf("0 should be inserted into main queue, keys %v", cache.Keys())
+	}
+	cache.Delete(0)
+	if _, exist := cache.ghost[0]; exist || cache.Contains(0) {
+		t.Errorf("0 should be deleted")
+	}
+}
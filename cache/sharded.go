@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// ShardedCache 并发安全的分片缓存
+// 原理：各个淘汰策略的Get也会修改链表，读写锁起不到并行读的作用，所以按key的hash分到N个分片，每个分片独立加互斥锁
+// 不同分片之间没有锁竞争，容量平均分给各个分片，淘汰只在分片内进行，整体上是近似的LRU/LFU
+
+// Policy 淘汰策略
+type Policy int
+
+const (
+	PolicyLRU Policy = iota
+	PolicyLFU
+	PolicyARC
+	PolicyTinyLFU
+	PolicySLRU
+	PolicyLRUK
+	PolicyClock
+	PolicySIEVE
+	PolicyS3FIFO
+)
+
+// cacheShard 单个分片
+type cacheShard[K comparable, V any] struct {
+	mu    sync.Mutex
+	cache Cache[K, V]
+}
+
+// ShardedCache 结构
+type ShardedCache[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []*cacheShard[K, V]
+}
+
+// newShardCache 按策略创建分片底层缓存
+func newShardCache[K comparable, V any](capacity int, policy Policy) Cache[K, V] {
+	switch policy {
+	case PolicyLFU:
+		return NewLFUCache[K, V](capacity)
+	case PolicyARC:
+		return NewARCCache[K, V](capacity)
+	case PolicyTinyLFU:
+		return NewTinyLFUCache[K, V](capacity)
+	case PolicySLRU:
+		return NewSLRUCache[K, V](capacity, 0.8)
+	case PolicyLRUK:
+		return NewLRUKCache[K, V](capacity, 2, capacity)
+	case PolicyClock:
+		return NewClockCache[K, V](capacity)
+	case PolicySIEVE:
+		return NewSIEVECache[K, V](capacity)
+	case PolicyS3FIFO:
+		return NewS3FIFOCache[K, V](capacity)
+	default:
+		return NewLRUCache[K, V](capacity)
+	}
+}
+
+// NewShardedCache constructor，capacity为总容量，平均分给shards个分片，除不尽的余数分给前面的分片
+// 分片数不超过capacity，否则容量为0的分片会丢弃落到它上面的所有key
+func NewShardedCache[K comparable, V any](shards int, capacity int, policy Policy) *ShardedCache[K, V] {
+	if shards > capacity {
+		shards = capacity
+	}
+	if shards <= 0 {
+		shards = 1
+	}
+	c := &ShardedCache[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*cacheShard[K, V], shards),
+	}
+	for i := range c.shards {
+		shardCap := capacity / shards
+		if i < capacity%shards {
+			shardCap++
+		}
+		c.shards[i] = &cacheShard[K, V]{cache: newShardCache[K, V](shardCap, policy)}
+	}
+	return c
+}
+
+// shard 定位key所在的分片
+func (c *ShardedCache[K, V]) shard(key K) *cacheShard[K, V] {
+	h := maphash.Comparable(c.seed, key)
+	return c.shards[h%uint64(len(c.shards))]
+}
+
+// Get 获取kv
+func (c *ShardedCache[K, V]) Get(key K) (V, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Get(key)
+}
+
+// Peek 获取kv，不影响淘汰顺序
+func (c *ShardedCache[K, V]) Peek(key K) (V, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Peek(key)
+}
+
+// Contains 判断key是否存在，不影响淘汰顺序
+func (c *ShardedCache[K, V]) Contains(key K) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Contains(key)
+}
+
+// Put 插入
+func (c *ShardedCache[K, V]) Put(key K, value V) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.Put(key, value)
+}
+
+// Delete 删除key
+func (c *ShardedCache[K, V]) Delete(key K) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.Delete(key)
+}
+
+// Len 所有分片的key数量之和，逐个分片加锁统计，并发写入时只是一个近似值
+func (c *ShardedCache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += s.cache.Len()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Keys 返回所有分片的key，分片之间没有顺序关系
+func (c *ShardedCache[K, V]) Keys() []K {
+	var keys []K
+	for _, s := range c.shards {
+		s.mu.Lock()
+		keys = append(keys, s.cache.Keys()...)
+		s.mu.Unlock()
+	}
+	return keys
+}
+
+// Stats 汇总所有分片的统计
+func (c *ShardedCache[K, V]) Stats() Stats {
+	var stats Stats
+	for _, s := range c.shards {
+		s.mu.Lock()
+		stats.add(s.cache.Stats())
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+// OnEvict 给所有分片设置淘汰回调，回调在持有分片锁时执行，不能再调用同一个缓存的方法
+func (c *ShardedCache[K, V]) OnEvict(fn EvictFunc[K, V]) {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.cache.OnEvict(fn)
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestShardedCapacity(t *testing.T) {
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyARC, PolicyTinyLFU, PolicySLRU, PolicyLRUK, PolicyClock, PolicySIEVE, PolicyS3FIFO} {
+		cache := NewShardedCache[int, int](8, 100, policy)
+		for i := 0; i < 1000; i++ {
+			cache.Put(i, i)
+		}
+		// 100/8，前4个分片13，后4个分片12
+		for i, s := range cache.shards {
+			expect := 12
+			if i < 4 {
+				expect = 13
+			}
+			if n := s.cache.Len(); n != expect {
+				t.Errorf("policy %d shard %d len expect %d, got %d", policy, i, expect, n)
+			}
+		}
+		if n := cache.Len(); n != 100 {
+			t.Errorf("policy %d len expect 100, got %d", policy, n)
+		}
+		if n := len(cache.Keys()); n != 100 {
+			t.Errorf("policy %d keys expect 100, got %d", policy, n)
+		}
+	}
+}
+
+func TestShardedSmallCapacity(t *testing.T) {
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyARC, PolicyTinyLFU, PolicySLRU, PolicyLRUK, PolicyClock, PolicySIEVE, PolicyS3FIFO} {
+		// 容量小于分片数时分片数收缩到容量，每个分片至少能放下1个key
+		cache := NewShardedCache[int, int](16, 3, policy)
+		if len(cache.shards) != 3 {
+			t.Fatalf("policy %d shards expect 3, got %d", policy, len(cache.shards))
+		}
+		for i := 0; i < 100; i++ {
+			cache.Put(i, i)
+			if v, ok := cache.Get(i); !ok || v != i {
+				t.Errorf("policy %d get %d just put, got %v, %v", policy, i, v, ok)
+			}
+		}
+		if n := cache.Len(); n < 1 || n > 3 {
+			t.Errorf("policy %d len expect at most 3, got %d", policy, n)
+		}
+	}
+}
+
+func TestShardedBasic(t *testing.T) {
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyARC, PolicyTinyLFU, PolicySLRU, PolicyLRUK, PolicyClock, PolicySIEVE, PolicyS3FIFO} {
+		cache := NewShardedCache[string, int](4, 64, policy)
+		cache.Put("a", 1)
+		cache.Put("b", 2)
+		cache.Put("a", 10)
+		if v, ok := cache.Get("a"); !ok || v != 10 {
+			t.Errorf("policy %d get a expect 10, got %v, %v", policy, v, ok)
+		}
+		if v, ok := cache.Peek("b"); !ok || v != 2 {
+			t.Errorf("policy %d peek b expect 2, got %v, %v", policy, v, ok)
+		}
+		cache.Delete("b")
+		if cache.Contains("b") || cache.Len() != 1 {
+			t.Errorf("policy %d delete b failed, len=%d", policy, cache.Len())
+		}
+	}
+}
+
+func TestShardedConcurrent(t *testing.T) {
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyARC, PolicyTinyLFU, PolicySLRU, PolicyLRUK, PolicyClock, PolicySIEVE, PolicyS3FIFO} {
+		cache := NewShardedCache[int, int](16, 256, policy)
+		concurrency := 16
+		iterations := 5000
+		wg := sync.WaitGroup{}
+		wg.Add(concurrency)
+		for n := 0; n < concurrency; n++ {
+			go func(n int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(n)))
+				for i := 0; i < iterations; i++ {
+					k := r.Intn(1024)
+					switch r.Intn(10) {
+					case 0:
+						cache.Delete(k)
+					case 1, 2, 3:
+						cache.Put(k, k*2)
+					case 4:
+						cache.Len()
+					default:
+						// 值只会是k*2，并发下也不能读到其他key的值
+						if v, ok := cache.Get(k); ok && v != k*2 {
+							t.Errorf("get %d expect %d, got %d", k, k*2, v)
+						}
+					}
+				}
+			}(n)
+		}
+		wg.Wait()
+		if n := cache.Len(); n > 256 {
+			t.Errorf("policy %d len %d exceeds capacity", policy, n)
+		}
+	}
+}
+
+func BenchmarkShardedParallel(b *testing.B) {
+	cache := NewShardedCache[int, int](64, 10000, PolicyLRU)
+	for i := 0; i < 10000; i++ {
+		cache.Put(i, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Intn(20000)
+			if _, ok := cache.Get(k); !ok {
+				cache.Put(k, k)
+			}
+		}
+	})
+}
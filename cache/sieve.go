@@ -0,0 +1,120 @@
+package cache
+
+// SIEVECache SIEVE缓存
+// 原理：所有key按写入顺序放在一个FIFO队列中，新key插入表头，命中时只设置访问位，不移动节点
+// 淘汰指针从表尾向表头移动，访问位为1的key清零后留在原位，遇到访问位为0的key则淘汰，指针停在它的前一个节点
+// 和CLOCK的区别是存活下来的key不会被移到表头，新key集中在表头附近，可以很快被淘汰掉
+// ref: https://www.usenix.org/conference/nsdi24/presentation/zhang-yazhuo
+
+// SIEVECache 结构
+type SIEVECache[K comparable, V any] struct {
+	metrics[K, V]
+	capacity int
+	store    map[K]*entry[K, V]
+	queue    entryList[K, V]
+	hand     *entry[K, V] // 淘汰指针，nil表示从表尾开始
+}
+
+// NewSIEVECache constructor
+func NewSIEVECache[K comparable, V any](capacity int) *SIEVECache[K, V] {
+	return &SIEVECache[K, V]{
+		capacity: capacity,
+		store:    map[K]*entry[K, V]{},
+	}
+}
+
+// remove 删除节点，淘汰指针指向被删除节点时前移
+func (c *SIEVECache[K, V]) remove(node *entry[K, V], reason EvictReason) {
+	if c.hand == node {
+		c.hand = node.pre
+	}
+	c.queue.unlink(node)
+	delete(c.store, node.key)
+	c.evicted(node.key, node.value, reason)
+}
+
+// evict 移动淘汰指针淘汰一个key
+func (c *SIEVECache[K, V]) evict() {
+	node := c.hand
+	if node == nil {
+		node = c.queue.tail
+	}
+	for node.freq > 0 {
+		node.freq = 0
+		node = node.pre
+		if node == nil {
+			node = c.queue.tail
+		}
+	}
+	c.hand = node
+	c.remove(node, EvictCapacity)
+}
+
+// Delete 删除key
+func (c *SIEVECache[K, V]) Delete(key K) {
+	if node, exist := c.store[key]; exist {
+		c.remove(node, EvictDelete)
+	}
+}
+
+// Get 获取kv，命中时设置访问位
+func (c *SIEVECache[K, V]) Get(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		c.miss()
+		return
+	}
+	c.hit()
+	node.freq = 1
+	return node.value, true
+}
+
+// Peek 获取kv，不设置访问位
+func (c *SIEVECache[K, V]) Peek(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		return
+	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不设置访问位
+func (c *SIEVECache[K, V]) Contains(key K) bool {
+	_, exist := c.store[key]
+	return exist
+}
+
+// Put 插入，已存在的key视为一次命中，新key插入表头
+func (c *SIEVECache[K, V]) Put(key K, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.put()
+	if node, exist := c.store[key]; exist {
+		old := node.value
+		node.value = value
+		node.freq = 1
+		c.evicted(key, old, EvictReplace)
+		return
+	}
+	if len(c.store) >= c.capacity {
+		c.evict()
+	}
+	node := &entry[K, V]{key: key, value: value}
+	c.queue.pushFront(node)
+	c.store[key] = node
+}
+
+// Len 当前存储的key数量
+func (c *SIEVECache[K, V]) Len() int {
+	return len(c.store)
+}
+
+// Keys 返回所有key，按写入顺序从新到旧
+func (c *SIEVECache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.store))
+	for node := c.queue.head; node != nil; node = node.next {
+		keys = append(keys, node.key)
+	}
+	return keys
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSIEVEEvict(t *testing.T) {
+	cache := NewSIEVECache[int, int](3)
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	cache.Put(3, 3)
+	cache.Get(1)
+	// 指针从表尾出发，1访问位清零留在原位，淘汰2，指针停在3
+	cache.Put(4, 4)
+	if got, want := cache.Keys(), []int{4, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+	cache.Get(1)
+	// 从3继续，淘汰3，不会回头检查1
+	cache.Put(5, 5)
+	if got, want := cache.Keys(), []int{5, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+	// 指针所在节点被删除后前移
+	cache.Delete(4)
+	cache.Put(6, 6)
+	cache.Put(7, 7)
+	if got, want := cache.Keys(), []int{7, 6, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"math"
+	"math/bits"
+)
+
+// CountMinSketch 4bit计数的Count-Min Sketch，用于估算key的访问频率
+// 原理：depth行计数器，每行用不同的hash定位一个计数器，写入时各行+1，估算时取各行最小值
+// 每个计数器只占4bit，最大计到15，一个uint64保存16个计数器
+// 老化：累计写入次数达到sampleSize之后所有计数器减半，使频率随时间衰减，适应访问模式的变化
+// ref: https://arxiv.org/abs/1512.00727
+type CountMinSketch struct {
+	rows       [cmDepth][]uint64
+	mask       uint64 // 每行计数器数量-1
+	additions  int
+	sampleSize int
+}
+
+const (
+	cmDepth   = 4
+	cmMaxFreq = 15
+)
+
+// 各行的hash种子
+var cmSeeds = [cmDepth]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+// NewCountMinSketch constructor，width为每行计数器数量，向上取整为2的n次方，写入10*width次后老化一次
+func NewCountMinSketch(width int) *CountMinSketch {
+	if width < 16 {
+		width = 16
+	}
+	width = 1 << bits.Len(uint(width-1))
+	s := &CountMinSketch{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, width/16)
+	}
+	return s
+}
+
+// index 第i行计数器的下标
+func (s *CountMinSketch) index(hash uint64, i int) uint64 {
+	h := (hash ^ cmSeeds[i]) * 0x9e3779b97f4a7c15
+	h ^= h >> 32
+	return h & s.mask
+}
+
+// counter 读取第i行下标为idx的计数器
+func (s *CountMinSketch) counter(i int, idx uint64) uint8 {
+	return uint8(s.rows[i][idx>>4]>>((idx&15)<<2)) & 0x0f
+}
+
+// Increment 记录一次访问，返回是否触发了老化
+func (s *CountMinSketch) Increment(hash uint64) bool {
+	for i := range s.rows {
+		idx := s.index(hash, i)
+		if s.counter(i, idx) < cmMaxFreq {
+			s.rows[i][idx>>4] += 1 << ((idx & 15) << 2)
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.Reset()
+		return true
+	}
+	return false
+}
+
+// Estimate 估算访问频率，取各行计数器的最小值
+func (s *CountMinSketch) Estimate(hash uint64) uint8 {
+	freq := uint8(cmMaxFreq)
+	for i := range s.rows {
+		freq = min(freq, s.counter(i, s.index(hash, i)))
+	}
+	return freq
+}
+
+// Reset 老化，所有计数器减半
+func (s *CountMinSketch) Reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			// 每个4bit计数器右移一位，屏蔽掉从高位计数器移过来的bit
+			s.rows[i][j] = (s.rows[i][j] >> 1) & 0x7777777777777777
+		}
+	}
+	s.additions /= 2
+}
+
+// Clear 清空所有计数器
+func (s *CountMinSketch) Clear() {
+	for i := range s.rows {
+		clear(s.rows[i])
+	}
+	s.additions = 0
+}
+
+// Doorkeeper 布隆过滤器，挡在CountMinSketch前面过滤只访问一次的key
+// 第一次访问只记录到Doorkeeper，之后的访问才计入sketch，节省sketch的计数空间
+// 老化时跟随sketch一起清空
+type Doorkeeper struct {
+	bits []uint64
+	mask uint64 // bit数量-1
+	k    int    // hash函数数量
+}
+
+// NewDoorkeeper constructor，n为预计插入数量，fpRate为期望的误判率
+func NewDoorkeeper(n int, fpRate float64) *Doorkeeper {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	// m = -n*ln(p)/(ln2)^2, k = m/n*ln2
+	m := int(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = max(1<<bits.Len(uint(m-1)), 64)
+	k := max(int(math.Round(float64(m)/float64(n)*math.Ln2)), 1)
+	return &Doorkeeper{
+		bits: make([]uint64, m/64),
+		mask: uint64(m - 1),
+		k:    k,
+	}
+}
+
+// locate 使用双重hash计算第i个bit位置
+func (d *Doorkeeper) locate(hash uint64, i int) uint64 {
+	h1 := hash
+	h2 := (hash >> 32) | (hash << 32) | 1
+	return (h1 + uint64(i)*h2) & d.mask
+}
+
+// Put 写入，返回写入前是否已经存在
+func (d *Doorkeeper) Put(hash uint64) bool {
+	exist := true
+	for i := 0; i < d.k; i++ {
+		pos := d.locate(hash, i)
+		if d.bits[pos>>6]&(1<<(pos&63)) == 0 {
+			exist = false
+			d.bits[pos>>6] |= 1 << (pos & 63)
+		}
+	}
+	return exist
+}
+
+// Contains 判断是否存在，有一定的误判率
+func (d *Doorkeeper) Contains(hash uint64) bool {
+	for i := 0; i < d.k; i++ {
+		pos := d.locate(hash, i)
+		if d.bits[pos>>6]&(1<<(pos&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reset 清空
+func (d *Doorkeeper) Reset() {
+	clear(d.bits)
+}
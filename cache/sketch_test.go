@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCountMinSketch(t *testing.T) {
+	s := NewCountMinSketch(1000)
+	if len(s.rows[0]) != 1024/16 {
+		t.Fatalf("width should round up to 1024, got %d", len(s.rows[0])*16)
+	}
+	for i := 0; i < 5; i++ {
+		s.Increment(1)
+	}
+	for i := 0; i < 20; i++ {
+		s.Increment(2)
+	}
+	if f := s.Estimate(1); f != 5 {
+		t.Errorf("estimate 1 expect 5, got %d", f)
+	}
+	// 4bit计数上限15
+	if f := s.Estimate(2); f != 15 {
+		t.Errorf("estimate 2 expect 15, got %d", f)
+	}
+	if f := s.Estimate(3); f != 0 {
+		t.Errorf("estimate 3 expect 0, got %d", f)
+	}
+
+	s.Reset()
+	if f1, f2 := s.Estimate(1), s.Estimate(2); f1 != 2 || f2 != 7 {
+		t.Errorf("after reset expect 2, 7, got %d, %d", f1, f2)
+	}
+	s.Clear()
+	if f := s.Estimate(2); f != 0 {
+		t.Errorf("after clear expect 0, got %d", f)
+	}
+}
+
+func TestCountMinSketchAging(t *testing.T) {
+	s := NewCountMinSketch(16)
+	r := rand.New(rand.NewSource(1))
+	resets := 0
+	for i := 0; i < s.sampleSize*3; i++ {
+		if s.Increment(r.Uint64()) {
+			resets++
+		}
+	}
+	if resets < 3 {
+		t.Errorf("should age at least 3 times, got %d", resets)
+	}
+	if s.additions >= s.sampleSize {
+		t.Errorf("additions %d should be less than sample size", s.additions)
+	}
+}
+
+func TestDoorkeeper(t *testing.T) {
+	n := 10000
+	d := NewDoorkeeper(n, 0.01)
+	r := rand.New(rand.NewSource(1))
+	keys := make([]uint64, n)
+	for i := range keys {
+		keys[i] = r.Uint64()
+		d.Put(keys[i])
+	}
+	for _, k := range keys {
+		if !d.Contains(k) {
+			t.Fatalf("key %d should exist", k)
+		}
+	}
+	fp := 0
+	for i := 0; i < n; i++ {
+		if d.Contains(r.Uint64()) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / float64(n); rate > 0.03 {
+		t.Errorf("false positive rate %.4f too high", rate)
+	}
+	d.Reset()
+	if d.Contains(keys[0]) {
+		t.Errorf("should be empty after reset")
+	}
+}
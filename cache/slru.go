@@ -0,0 +1,119 @@
+package cache
+
+// SLRUCache 分段LRU(Segmented LRU)
+// 原理：缓存分为试用区和保护区两段LRU，新key进入试用区，试用区中再次命中的key晋升到保护区
+// 保护区超过容量时表尾降级回试用区表头，淘汰只发生在试用区表尾
+// 只访问一次的扫描数据停留在试用区，不会冲掉保护区中的热点数据
+// ref: https://en.wikipedia.org/wiki/Cache_replacement_policies#Segmented_LRU_(SLRU)
+
+// SLRUCache 结构
+type SLRUCache[K comparable, V any] struct {
+	metrics[K, V]
+	capacity     int
+	protectedCap int
+	store        map[K]*entry[K, V]
+	probation    entryList[K, V]
+	protected    entryList[K, V]
+}
+
+// NewSLRUCache constructor，protectedRatio为保护区占总容量的比例，取值(0, 1)，否则使用默认值0.8
+func NewSLRUCache[K comparable, V any](capacity int, protectedRatio float64) *SLRUCache[K, V] {
+	if protectedRatio <= 0 || protectedRatio >= 1 {
+		protectedRatio = 0.8
+	}
+	return &SLRUCache[K, V]{
+		capacity:     capacity,
+		protectedCap: int(float64(capacity) * protectedRatio),
+		store:        map[K]*entry[K, V]{},
+	}
+}
+
+// touch 命中时调整节点位置
+func (c *SLRUCache[K, V]) touch(node *entry[K, V]) {
+	node.moveTo(&c.protected)
+	if c.protected.length > c.protectedCap {
+		c.protected.tail.moveTo(&c.probation)
+	}
+}
+
+// remove 删除节点
+func (c *SLRUCache[K, V]) remove(node *entry[K, V], reason EvictReason) {
+	node.list.unlink(node)
+	delete(c.store, node.key)
+	c.evicted(node.key, node.value, reason)
+}
+
+// Delete 删除key
+func (c *SLRUCache[K, V]) Delete(key K) {
+	if node, exist := c.store[key]; exist {
+		c.remove(node, EvictDelete)
+	}
+}
+
+// Get 获取kv，命中时晋升到保护区表头
+func (c *SLRUCache[K, V]) Get(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		c.miss()
+		return
+	}
+	c.hit()
+	c.touch(node)
+	return node.value, true
+}
+
+// Peek 获取kv，不影响淘汰顺序
+func (c *SLRUCache[K, V]) Peek(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		return
+	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不影响淘汰顺序
+func (c *SLRUCache[K, V]) Contains(key K) bool {
+	_, exist := c.store[key]
+	return exist
+}
+
+// Put 插入，新key进入试用区，已存在的key视为一次命中
+func (c *SLRUCache[K, V]) Put(key K, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.put()
+	if node, exist := c.store[key]; exist {
+		old := node.value
+		node.value = value
+		c.touch(node)
+		c.evicted(key, old, EvictReplace)
+		return
+	}
+	if len(c.store) >= c.capacity {
+		victim := c.probation.tail
+		if victim == nil {
+			victim = c.protected.tail
+		}
+		c.remove(victim, EvictCapacity)
+	}
+	node := &entry[K, V]{key: key, value: value}
+	c.probation.pushFront(node)
+	c.store[key] = node
+}
+
+// Len 当前存储的key数量
+func (c *SLRUCache[K, V]) Len() int {
+	return len(c.store)
+}
+
+// Keys 返回所有key，先保护区后试用区，区内按新鲜度从新到旧
+func (c *SLRUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.store))
+	for _, l := range []*entryList[K, V]{&c.protected, &c.probation} {
+		for node := l.head; node != nil; node = node.next {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSLRUScanResistant(t *testing.T) {
+	cache := NewSLRUCache[int, int](10, 0.8)
+	// 热点key访问两次晋升到保护区
+	for i := 0; i < 5; i++ {
+		cache.Put(i, i)
+		cache.Get(i)
+	}
+	// 只访问一次的扫描key只会在试用区中互相淘汰
+	for i := 100; i < 1000; i++ {
+		cache.Put(i, i)
+	}
+	for i := 0; i < 5; i++ {
+		if v, ok := cache.Get(i); !ok || v != i {
+			t.Errorf("hot key %d should survive scan, got %v, %v", i, v, ok)
+		}
+	}
+	if cache.Len() != 10 {
+		t.Errorf("len expect 10, got %d", cache.Len())
+	}
+}
+
+func TestSLRUDemote(t *testing.T) {
+	cache := NewSLRUCache[int, int](4, 0.5)
+	for i := 0; i < 4; i++ {
+		cache.Put(i, i)
+	}
+	// 保护区容量为2，晋升第三个key时保护区表尾0降级到试用区表头
+	cache.Get(0)
+	cache.Get(1)
+	cache.Get(2)
+	if got, want := cache.Keys(), []int{2, 1, 0, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+	// 淘汰试用区表尾3
+	cache.Put(4, 4)
+	if cache.Contains(3) {
+		t.Errorf("3 should be evicted")
+	}
+	if got, want := cache.Keys(), []int{2, 1, 4, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+	cache.Delete(1)
+	if cache.Contains(1) || cache.Len() != 3 {
+		t.Errorf("delete 1 failed, keys %v", cache.Keys())
+	}
+}
+
+func TestSLRUStatsAndEvict(t *testing.T) {
+	cache := NewSLRUCache[int, int](4, 0.5)
+	var records []evictRecord
+	cache.OnEvict(func(key int, value int, reason EvictReason) {
+		records = append(records, evictRecord{key, value, reason})
+	})
+	for i := 0; i < 4; i++ {
+		cache.Put(i, i)
+	}
+	// 保护区和试用区之间的晋升、降级不是淘汰，不触发回调
+	cache.Get(0)
+	cache.Get(1)
+	cache.Get(2)
+	cache.Get(9)
+	cache.Put(2, 20)
+	cache.Put(4, 4)
+	cache.Delete(1)
+	want := []evictRecord{{2, 2, EvictReplace}, {3, 3, EvictCapacity}, {1, 1, EvictDelete}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records expect %v, got %v", want, records)
+	}
+	if stats := cache.Stats(); stats.Hits != 3 || stats.Misses != 1 || stats.Puts != 6 || stats.Evictions != 1 {
+		t.Errorf("stats expect 3 hits, 1 miss, 6 puts, 1 eviction, got %+v", stats)
+	}
+}
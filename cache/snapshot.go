@@ -0,0 +1,304 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// 快照格式，所有整数都是varint编码：
+//
+//	magic "DSCS" | version(1B) | kind(1B) | count | record... | crc32(4B，大端，覆盖前面所有字节)
+//
+// record为 key长度 | key | value长度 | value | 策略相关的元数据
+//   - LRU：过期时间(unix纳秒，0表示不过期)，按新鲜度从旧到新排列，恢复时依次插入表头即可还原顺序
+//   - LFU：访问频率，按频率升序、同频率从旧到新排列
+//
+// 快照先完整读入内存校验crc，解码和容量检查都通过之后才替换缓存内容，失败时缓存保持不变
+
+const (
+	snapshotMagic   = "DSCS"
+	snapshotVersion = 1
+
+	snapshotLRU byte = 1
+	snapshotLFU byte = 2
+)
+
+var (
+	ErrSnapshotCorrupt  = errors.New("cache: snapshot is corrupt")
+	ErrSnapshotVersion  = errors.New("cache: unsupported snapshot version")
+	ErrSnapshotKind     = errors.New("cache: snapshot belongs to another cache policy")
+	ErrSnapshotCapacity = errors.New("cache: snapshot exceeds cache capacity")
+)
+
+// snapshotWriter 在内存中拼装快照
+type snapshotWriter struct {
+	buf   []byte
+	count int
+}
+
+func (w *snapshotWriter) bytes(b []byte) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *snapshotWriter) varint(v int64) {
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+// record 写入一条记录的key和value，元数据由调用方接着写入
+func (w *snapshotWriter) record(key, value []byte) {
+	w.bytes(key)
+	w.bytes(value)
+	w.count++
+}
+
+// writeTo 加上头部和crc写出
+func (w *snapshotWriter) writeTo(dst io.Writer, kind byte) error {
+	out := make([]byte, 0, len(snapshotMagic)+2+binary.MaxVarintLen64+len(w.buf)+4)
+	out = append(out, snapshotMagic...)
+	out = append(out, snapshotVersion, kind)
+	out = binary.AppendUvarint(out, uint64(w.count))
+	out = append(out, w.buf...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+	_, err := dst.Write(out)
+	return err
+}
+
+// snapshotReader 解析快照，出错后后续读取都返回零值，最后统一检查err
+type snapshotReader struct {
+	data []byte
+	err  error
+}
+
+// readSnapshot 读入并校验快照头部和crc，返回记录数量
+func readSnapshot(src io.Reader, kind byte) (*snapshotReader, int, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) < len(snapshotMagic)+2+1+4 || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, 0, ErrSnapshotCorrupt
+	}
+	body, sum := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return nil, 0, ErrSnapshotCorrupt
+	}
+	if body[len(snapshotMagic)] != snapshotVersion {
+		return nil, 0, ErrSnapshotVersion
+	}
+	if body[len(snapshotMagic)+1] != kind {
+		return nil, 0, ErrSnapshotKind
+	}
+	r := &snapshotReader{data: body[len(snapshotMagic)+2:]}
+	count := r.uvarint()
+	// 每条记录至少2个字节，提前拦住伪造的超大数量
+	if r.err != nil || count > uint64(len(r.data))/2 {
+		return nil, 0, ErrSnapshotCorrupt
+	}
+	return r, int(count), nil
+}
+
+func (r *snapshotReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = ErrSnapshotCorrupt
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *snapshotReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = ErrSnapshotCorrupt
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *snapshotReader) bytes() []byte {
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.data)) {
+		r.err = ErrSnapshotCorrupt
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+// readRecord 读取并解码一条记录的key和value
+func readRecord[K comparable, V any](r *snapshotReader, c *codecs[K, V]) (key K, value V, err error) {
+	kb, vb := r.bytes(), r.bytes()
+	if r.err != nil {
+		return key, value, r.err
+	}
+	if key, err = c.keys().Unmarshal(kb); err != nil {
+		return
+	}
+	value, err = c.values().Unmarshal(vb)
+	return
+}
+
+// finish 检查是否正好读完
+func (r *snapshotReader) finish() error {
+	if r.err == nil && len(r.data) != 0 {
+		r.err = ErrSnapshotCorrupt
+	}
+	return r.err
+}
+
+// Snapshot 把未过期的kv按新鲜度写入w
+func (c *LRUCache[K, V]) Snapshot(w io.Writer) error {
+	c.mu.Lock()
+	var sw snapshotWriter
+	now := c.now().UnixNano()
+	for node := c.tail; node != nil; node = node.pre {
+		if c.expired(node, now) {
+			continue
+		}
+		kb, err := c.keys().Marshal(node.key)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		vb, err := c.values().Marshal(node.value)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		sw.record(kb, vb)
+		sw.varint(node.expireAt)
+	}
+	c.mu.Unlock()
+	return sw.writeTo(w, snapshotLRU)
+}
+
+// Restore 用快照替换缓存内容，保留新鲜度顺序和过期时间，已经过期的kv直接丢弃
+// 快照的总开销超过maxCost时返回ErrSnapshotCapacity，被替换掉的kv不触发淘汰回调
+func (c *LRUCache[K, V]) Restore(r io.Reader) error {
+	sr, count, err := readSnapshot(r, snapshotLRU)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now().UnixNano()
+	nodes := make([]*LRUChainNode[K, V], 0, count)
+	var cost int64
+	seen := make(map[K]struct{}, count)
+	for i := 0; i < count; i++ {
+		key, value, err := readRecord(sr, &c.codecs)
+		if err != nil {
+			return err
+		}
+		expireAt := sr.varint()
+		if _, dup := seen[key]; dup {
+			return ErrSnapshotCorrupt
+		}
+		seen[key] = struct{}{}
+		if expireAt != 0 && expireAt <= now {
+			continue
+		}
+		node := &LRUChainNode[K, V]{key: key, value: value, cost: c.weigh(key, value), expireAt: expireAt}
+		cost += node.cost
+		nodes = append(nodes, node)
+	}
+	if err := sr.finish(); err != nil {
+		return err
+	}
+	if cost > c.maxCost {
+		return ErrSnapshotCapacity
+	}
+	c.store = make(map[K]*LRUChainNode[K, V], len(nodes))
+	c.head, c.tail, c.expHead, c.expTail = nil, nil, nil, nil
+	c.length, c.cost = len(nodes), cost
+	for _, node := range nodes {
+		c.pushFront(node)
+		c.linkExpire(node)
+		c.store[node.key] = node
+	}
+	return nil
+}
+
+// Snapshot 把kv连同访问频率写入w
+func (c *LFUCache[K, V]) Snapshot(w io.Writer) error {
+	var sw snapshotWriter
+	for b := c.head; b != nil; b = b.next {
+		for node := b.tail; node != nil; node = node.pre {
+			kb, err := c.keys().Marshal(node.key)
+			if err != nil {
+				return err
+			}
+			vb, err := c.values().Marshal(node.value)
+			if err != nil {
+				return err
+			}
+			sw.record(kb, vb)
+			sw.varint(int64(b.freq))
+		}
+	}
+	return sw.writeTo(w, snapshotLFU)
+}
+
+// Restore 用快照替换缓存内容，保留访问频率和同频率下的新鲜度顺序
+// 快照的总开销超过maxCost时返回ErrSnapshotCapacity，被替换掉的kv不触发淘汰回调
+func (c *LFUCache[K, V]) Restore(r io.Reader) error {
+	sr, count, err := readSnapshot(r, snapshotLFU)
+	if err != nil {
+		return err
+	}
+	type record struct {
+		node *LFUChainNode[K, V]
+		freq int
+	}
+	records := make([]record, 0, count)
+	var cost int64
+	seen := make(map[K]struct{}, count)
+	for i := 0; i < count; i++ {
+		key, value, err := readRecord(sr, &c.codecs)
+		if err != nil {
+			return err
+		}
+		freq := sr.varint()
+		// 频率必须为正且升序，key不能重复
+		if _, dup := seen[key]; dup || freq < 1 || len(records) > 0 && int(freq) < records[len(records)-1].freq {
+			return ErrSnapshotCorrupt
+		}
+		seen[key] = struct{}{}
+		node := &LFUChainNode[K, V]{key: key, value: value, cost: c.weigh(key, value)}
+		cost += node.cost
+		records = append(records, record{node, int(freq)})
+	}
+	if err := sr.finish(); err != nil {
+		return err
+	}
+	if cost > c.maxCost {
+		return ErrSnapshotCapacity
+	}
+	c.store = make(map[K]*LFUChainNode[K, V], len(records))
+	c.head, c.tail = nil, nil
+	c.length, c.cost = len(records), cost
+	for _, rec := range records {
+		b := c.tail
+		if b == nil || b.freq != rec.freq {
+			b = c.insertBucketAfter(c.tail, rec.freq)
+		}
+		b.pushFront(rec.node)
+		c.store[rec.node.key] = rec.node
+	}
+	return nil
+}
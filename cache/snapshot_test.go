@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLRUSnapshot(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := NewLRUCache[string, []byte](4)
+	cache.SetClock(clock.Now)
+	cache.SetCodec(StringCodec{}, BytesCodec{})
+	cache.Put("a", []byte("1"))
+	cache.PutWithTTL("b", []byte("2"), time.Minute)
+	cache.PutWithTTL("c", []byte("3"), time.Second)
+	cache.Put("d", []byte("4"))
+	cache.Get("a")
+
+	var buf bytes.Buffer
+	if err := cache.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Second)
+	restored := NewLRUCache[string, []byte](3)
+	restored.SetClock(clock.Now)
+	restored.SetCodec(StringCodec{}, BytesCodec{})
+	restored.Put("x", []byte("x"))
+	if err := restored.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	// c已经过期，x被替换掉，顺序保持不变
+	if got, want := restored.Keys(), []string{"a", "d", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+	if v, ok := restored.Peek("a"); !ok || string(v) != "1" {
+		t.Errorf("a expect 1, got %q, %v", v, ok)
+	}
+	clock.Advance(time.Minute)
+	if restored.RemoveExpired() != 1 || restored.Contains("b") {
+		t.Errorf("b should keep its ttl after restore")
+	}
+
+	// 超过容量
+	small := NewLRUCache[string, []byte](1)
+	small.SetCodec(StringCodec{}, BytesCodec{})
+	small.Put("y", []byte("y"))
+	if err := small.Restore(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrSnapshotCapacity) {
+		t.Errorf("expect ErrSnapshotCapacity, got %v", err)
+	}
+	if !reflect.DeepEqual(small.Keys(), []string{"y"}) {
+		t.Errorf("failed restore should keep old content, got %v", small.Keys())
+	}
+}
+
+func TestLFUSnapshot(t *testing.T) {
+	cache := NewLFUCache[int, string](4)
+	cache.SetCodec(nil, JSONCodec[string]{})
+	for i := 1; i <= 4; i++ {
+		cache.Put(i, string(rune('a'+i)))
+		for j := 1; j < i; j++ {
+			cache.Get(i)
+		}
+	}
+	cache.Get(1)
+	var buf bytes.Buffer
+	if err := cache.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewLFUCache[int, string](4)
+	restored.SetCodec(nil, JSONCodec[string]{})
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := restored.Keys(), cache.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("keys expect %v, got %v", want, got)
+	}
+	// 频率也一并恢复：1和2同为频率2，淘汰更久未使用的2
+	restored.Put(5, "f")
+	if restored.Contains(2) || !restored.Contains(1) {
+		t.Errorf("2 should be evicted, keys %v", restored.Keys())
+	}
+	if v, ok := restored.Peek(4); !ok || v != "e" {
+		t.Errorf("4 expect e, got %q, %v", v, ok)
+	}
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	cache := NewLRUCache[int, int](4)
+	cache.Put(1, 1)
+	var buf bytes.Buffer
+	if err := cache.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)/2] ^= 0xff
+	if err := NewLRUCache[int, int](4).Restore(bytes.NewReader(flipped)); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("expect ErrSnapshotCorrupt, got %v", err)
+	}
+	if err := NewLRUCache[int, int](4).Restore(bytes.NewReader(data[:len(data)-1])); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("expect ErrSnapshotCorrupt, got %v", err)
+	}
+	if err := NewLFUCache[int, int](4).Restore(bytes.NewReader(data)); !errors.Is(err, ErrSnapshotKind) {
+		t.Errorf("expect ErrSnapshotKind, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+// Stats 缓存统计的快照
+type Stats struct {
+	Hits        uint64 // Get命中次数
+	Misses      uint64 // Get未命中次数
+	Puts        uint64 // Put次数
+	Evictions   uint64 // 容量不足淘汰的次数
+	Expirations uint64 // 过期清理的次数
+}
+
+// HitRatio 命中率，没有Get请求时返回0
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// add 累加另外一份统计，用于分片汇总
+func (s *Stats) add(o Stats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Puts += o.Puts
+	s.Evictions += o.Evictions
+	s.Expirations += o.Expirations
+}
+
+// Weigher 计算kv的开销，例如value占用的字节数
+type Weigher[K comparable, V any] func(key K, value V) int64
+
+// EvictReason 淘汰原因
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota // 容量不足被淘汰
+	EvictDelete                      // 主动删除
+	EvictReplace                     // 覆盖写替换了旧值
+	EvictExpire                      // 过期
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictDelete:
+		return "delete"
+	case EvictReplace:
+		return "replace"
+	case EvictExpire:
+		return "expire"
+	}
+	return "unknown"
+}
+
+// EvictFunc 淘汰回调，在缓存内部调用，回调中不能再操作同一个缓存
+type EvictFunc[K comparable, V any] func(key K, value V, reason EvictReason)
+
+// metrics 内嵌到各个缓存中的统计和淘汰回调
+type metrics[K comparable, V any] struct {
+	stats   Stats
+	onEvict EvictFunc[K, V]
+}
+
+// Stats 返回统计快照
+func (m *metrics[K, V]) Stats() Stats {
+	return m.stats
+}
+
+// OnEvict 设置淘汰回调，容量淘汰、删除、覆盖写、过期时都会触发，nil表示取消回调
+func (m *metrics[K, V]) OnEvict(fn EvictFunc[K, V]) {
+	m.onEvict = fn
+}
+
+func (m *metrics[K, V]) hit() {
+	m.stats.Hits++
+}
+
+func (m *metrics[K, V]) miss() {
+	m.stats.Misses++
+}
+
+func (m *metrics[K, V]) put() {
+	m.stats.Puts++
+}
+
+// evicted 记录一次淘汰并触发回调
+func (m *metrics[K, V]) evicted(key K, value V, reason EvictReason) {
+	switch reason {
+	case EvictCapacity:
+		m.stats.Evictions++
+	case EvictExpire:
+		m.stats.Expirations++
+	}
+	if m.onEvict != nil {
+		m.onEvict(key, value, reason)
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+type evictRecord struct {
+	key    int
+	value  int
+	reason EvictReason
+}
+
+func TestStatsAndEvict(t *testing.T) {
+	for _, tc := range cacheCases {
+		name, cache := tc.name, tc.newCache(2)
+		var records []evictRecord
+		cache.OnEvict(func(key int, value int, reason EvictReason) {
+			records = append(records, evictRecord{key, value, reason})
+		})
+		cache.Put(1, 1)
+		cache.Put(1, 10) // 替换
+		cache.Get(1)
+		cache.Get(2)
+		cache.Put(2, 2)
+		cache.Delete(2) // 删除
+		cache.Delete(3) // 不存在，不触发回调
+		for i := 3; i < 10; i++ {
+			cache.Put(i, i) // 容量淘汰
+		}
+
+		stats := cache.Stats()
+		if stats.Hits != 1 || stats.Misses != 1 || stats.Puts != 10 {
+			t.Errorf("%s stats expect 1 hit, 1 miss, 10 puts, got %+v", name, stats)
+		}
+		if r := stats.HitRatio(); r != 0.5 {
+			t.Errorf("%s hit ratio expect 0.5, got %v", name, r)
+		}
+		if len(records) < 2 || records[0] != (evictRecord{1, 1, EvictReplace}) || records[1] != (evictRecord{2, 2, EvictDelete}) {
+			t.Fatalf("%s records expect replace then delete, got %v", name, records)
+		}
+		capacityEvictions := 0
+		for _, r := range records[2:] {
+			expect := r.key
+			if r.key == 1 {
+				expect = 10
+			}
+			if r.reason != EvictCapacity || r.value != expect {
+				t.Errorf("%s unexpected record %v", name, r)
+			}
+			capacityEvictions++
+		}
+		// 8个key写入容量为2的缓存，最终剩下Len个，其余都被淘汰
+		if expect := 8 - cache.Len(); capacityEvictions != expect || stats.Evictions != uint64(expect) {
+			t.Errorf("%s capacity evictions expect %d, got %d records, %d stats", name, expect, capacityEvictions, stats.Evictions)
+		}
+	}
+}
+
+func TestStatsExpire(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewLRUCacheWithTTL[int, int](10, time.Second)
+	cache.SetClock(clock.Now)
+	expired := 0
+	cache.OnEvict(func(key int, value int, reason EvictReason) {
+		if reason == EvictExpire {
+			expired++
+		}
+	})
+	for i := 0; i < 5; i++ {
+		cache.Put(i, i)
+	}
+	clock.Advance(time.Second)
+	cache.Get(0)
+	cache.RemoveExpired()
+	if stats := cache.Stats(); stats.Expirations != 5 || stats.Misses != 1 || expired != 5 {
+		t.Errorf("expect 5 expirations and 1 miss, got %+v, callback %d", stats, expired)
+	}
+}
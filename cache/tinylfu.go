@@ -0,0 +1,180 @@
+package cache
+
+import "hash/maphash"
+
+// TinyLFUCache W-TinyLFU缓存
+// 原理：新key先进入一个占1%容量的LRU窗口，窗口淘汰的key作为候选者尝试进入主区域
+// 主区域是分段LRU(SLRU)，分为试用区(20%)和保护区(80%)，试用区再次命中的key晋升到保护区，保护区溢出的key降级回试用区
+// 主区域已满时，候选者和试用区表尾的牺牲者比较估算频率，候选者频率更高才准入，否则直接淘汰候选者
+// 频率由TinyLFU估算：Doorkeeper过滤只访问一次的key，CountMinSketch记录被淘汰key的历史频率并定期老化
+// ref: https://arxiv.org/abs/1512.00727
+
+// TinyLFUCache 结构
+type TinyLFUCache[K comparable, V any] struct {
+	metrics[K, V]
+	capacity     int
+	windowCap    int
+	protectedCap int
+	seed         maphash.Seed
+	store        map[K]*entry[K, V]
+	window       entryList[K, V]
+	probation    entryList[K, V]
+	protected    entryList[K, V]
+	sketch       *CountMinSketch
+	doorkeeper   *Doorkeeper
+}
+
+// NewTinyLFUCache constructor
+func NewTinyLFUCache[K comparable, V any](capacity int) *TinyLFUCache[K, V] {
+	windowCap := max(capacity/100, 1)
+	mainCap := max(capacity-windowCap, 0)
+	sketch := NewCountMinSketch(capacity)
+	return &TinyLFUCache[K, V]{
+		capacity:     capacity,
+		windowCap:    windowCap,
+		protectedCap: mainCap * 8 / 10,
+		seed:         maphash.MakeSeed(),
+		store:        map[K]*entry[K, V]{},
+		sketch:       sketch,
+		doorkeeper:   NewDoorkeeper(sketch.sampleSize, 0.01),
+	}
+}
+
+// hash 计算key的hash
+func (c *TinyLFUCache[K, V]) hash(key K) uint64 {
+	return maphash.Comparable(c.seed, key)
+}
+
+// record 记录一次访问，第一次访问只写入doorkeeper
+func (c *TinyLFUCache[K, V]) record(key K) {
+	h := c.hash(key)
+	if !c.doorkeeper.Put(h) {
+		return
+	}
+	if c.sketch.Increment(h) {
+		c.doorkeeper.Reset()
+	}
+}
+
+// frequency 估算访问频率
+func (c *TinyLFUCache[K, V]) frequency(key K) int {
+	h := c.hash(key)
+	freq := int(c.sketch.Estimate(h))
+	if c.doorkeeper.Contains(h) {
+		freq++
+	}
+	return freq
+}
+
+// evict 淘汰节点
+func (c *TinyLFUCache[K, V]) evict(node *entry[K, V], reason EvictReason) {
+	node.list.unlink(node)
+	delete(c.store, node.key)
+	c.evicted(node.key, node.value, reason)
+}
+
+// touch 命中时调整节点位置
+func (c *TinyLFUCache[K, V]) touch(node *entry[K, V]) {
+	switch node.list {
+	case &c.window, &c.protected:
+		node.moveTo(node.list)
+	case &c.probation:
+		// 试用区命中晋升到保护区，保护区溢出则降级表尾到试用区
+		node.moveTo(&c.protected)
+		if c.protected.length > c.protectedCap {
+			c.protected.tail.moveTo(&c.probation)
+		}
+	}
+}
+
+// admit 窗口溢出时，窗口表尾的候选者尝试进入主区域
+func (c *TinyLFUCache[K, V]) admit() {
+	candidate := c.window.tail
+	if c.probation.length+c.protected.length < c.capacity-c.windowCap {
+		candidate.moveTo(&c.probation)
+		return
+	}
+	victim := c.probation.tail
+	if victim == nil {
+		victim = c.protected.tail
+	}
+	if victim == nil || c.frequency(candidate.key) <= c.frequency(victim.key) {
+		c.evict(candidate, EvictCapacity)
+		return
+	}
+	c.evict(victim, EvictCapacity)
+	candidate.moveTo(&c.probation)
+}
+
+// Delete 删除key
+func (c *TinyLFUCache[K, V]) Delete(key K) {
+	if node, exist := c.store[key]; exist {
+		c.evict(node, EvictDelete)
+	}
+}
+
+// Get 获取kv，不论是否命中都会记录访问频率
+func (c *TinyLFUCache[K, V]) Get(key K) (value V, ok bool) {
+	c.record(key)
+	node, exist := c.store[key]
+	if !exist {
+		c.miss()
+		return
+	}
+	c.hit()
+	c.touch(node)
+	return node.value, true
+}
+
+// Peek 获取kv，不记录访问频率
+func (c *TinyLFUCache[K, V]) Peek(key K) (value V, ok bool) {
+	node, exist := c.store[key]
+	if !exist {
+		return
+	}
+	return node.value, true
+}
+
+// Contains 判断key是否存在，不记录访问频率
+func (c *TinyLFUCache[K, V]) Contains(key K) bool {
+	_, exist := c.store[key]
+	return exist
+}
+
+// Put 插入，新key进入窗口，窗口溢出时触发准入判断，所以新key不一定能留在缓存中
+func (c *TinyLFUCache[K, V]) Put(key K, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.put()
+	c.record(key)
+	if node, exist := c.store[key]; exist {
+		old := node.value
+		node.value = value
+		c.touch(node)
+		c.evicted(key, old, EvictReplace)
+		return
+	}
+	node := &entry[K, V]{key: key, value: value}
+	c.window.pushFront(node)
+	c.store[key] = node
+	if c.window.length > c.windowCap {
+		c.admit()
+	}
+}
+
+// Len 当前存储的key数量
+func (c *TinyLFUCache[K, V]) Len() int {
+	return len(c.store)
+}
+
+// Keys 返回所有key，依次为保护区、试用区、窗口，区内按新鲜度从新到旧
+func (c *TinyLFUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.store))
+	for _, l := range []*entryList[K, V]{&c.protected, &c.probation, &c.window} {
+		for node := l.head; node != nil; node = node.next {
+			keys = append(keys, node.key)
+		}
+	}
+	return keys
+}
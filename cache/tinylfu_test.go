@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTinyLFUAdmission(t *testing.T) {
+	cache := NewTinyLFUCache[int, int](100)
+	// 热点key多次访问，进入主区域
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 50; i++ {
+			if _, ok := cache.Get(i); !ok {
+				cache.Put(i, i)
+			}
+		}
+	}
+	// 大量只访问一次的key不能挤掉热点key
+	for i := 1000; i < 2000; i++ {
+		cache.Put(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		if v, ok := cache.Peek(i); !ok || v != i {
+			t.Errorf("hot key %d should survive, got %v, %v", i, v, ok)
+		}
+	}
+	if cache.Len() > 100 {
+		t.Errorf("len %d exceeds capacity", cache.Len())
+	}
+}
+
+func TestTinyLFUBasic(t *testing.T) {
+	cache := NewTinyLFUCache[string, int](10)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Put("a", 10)
+	if v, ok := cache.Get("a"); !ok || v != 10 {
+		t.Errorf("get a expect 10, got %v, %v", v, ok)
+	}
+	cache.Delete("a")
+	if cache.Contains("a") || cache.Len() != 1 {
+		t.Errorf("delete a failed, len=%d", cache.Len())
+	}
+
+	one := NewTinyLFUCache[int, int](1)
+	one.Put(1, 1)
+	one.Put(2, 2)
+	if one.Len() != 1 || !one.Contains(2) {
+		t.Errorf("capacity 1 should keep the newest key, keys=%v", one.Keys())
+	}
+}
+
+func TestTinyLFUBounds(t *testing.T) {
+	capacity := 200
+	cache := NewTinyLFUCache[int, int](capacity)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		k := int(r.ExpFloat64() * 100)
+		switch r.Intn(10) {
+		case 0:
+			cache.Delete(k)
+		case 1, 2, 3:
+			cache.Put(k, k)
+		default:
+			if v, ok := cache.Get(k); ok && v != k {
+				t.Fatalf("get %d got %d", k, v)
+			}
+		}
+		if cache.Len() > capacity {
+			t.Fatalf("len %d exceeds capacity", cache.Len())
+		}
+		if cache.window.length > cache.windowCap || cache.protected.length > cache.protectedCap {
+			t.Fatalf("segment overflow, window %d, protected %d", cache.window.length, cache.protected.length)
+		}
+	}
+}
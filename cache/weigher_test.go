@@ -0,0 +1,69 @@
+package cache
+
+import "testing"
+
+// weighedCache 按开销淘汰的测试需要的方法
+type weighedCache interface {
+	Cache[string, []byte]
+	Cost() int64
+}
+
+func TestWeigher(t *testing.T) {
+	weigher := func(key string, value []byte) int64 {
+		return int64(len(value))
+	}
+	policies := map[string]weighedCache{
+		"lru": NewLRUCacheWithWeigher[string, []byte](100, weigher),
+		"lfu": NewLFUCacheWithWeigher[string, []byte](100, weigher),
+	}
+	for name, cache := range policies {
+		cache.Put("a", make([]byte, 40))
+		cache.Put("b", make([]byte, 40))
+		cache.Get("a")
+		cache.Get("a")
+		if cache.Cost() != 80 || cache.Len() != 2 {
+			t.Errorf("%s cost expect 80 with 2 keys, got %d with %d", name, cache.Cost(), cache.Len())
+		}
+
+		// 一个大value需要淘汰多个key，这里只需要淘汰b
+		cache.Put("c", make([]byte, 60))
+		if cache.Contains("b") || !cache.Contains("a") || cache.Cost() != 100 {
+			t.Errorf("%s put c should evict b only, keys=%v cost=%d", name, cache.Keys(), cache.Cost())
+		}
+		cache.Put("d", make([]byte, 90))
+		if cache.Len() != 1 || !cache.Contains("d") || cache.Cost() != 90 {
+			t.Errorf("%s put d should evict others, keys=%v cost=%d", name, cache.Keys(), cache.Cost())
+		}
+
+		// 覆盖写调整开销
+		cache.Put("d", make([]byte, 10))
+		cache.Put("e", make([]byte, 50))
+		if cache.Cost() != 60 || cache.Len() != 2 {
+			t.Errorf("%s cost expect 60 with 2 keys, got %d with %d", name, cache.Cost(), cache.Len())
+		}
+		// 覆盖写变大，淘汰其他key，不淘汰自己
+		cache.Put("d", make([]byte, 80))
+		if !cache.Contains("d") || cache.Contains("e") || cache.Cost() != 80 {
+			t.Errorf("%s grow d should evict e, keys=%v cost=%d", name, cache.Keys(), cache.Cost())
+		}
+
+		// 超过总预算直接拒绝，旧值也删除
+		cache.Put("d", make([]byte, 101))
+		cache.Put("f", make([]byte, 101))
+		if cache.Contains("d") || cache.Contains("f") || cache.Cost() != 0 || cache.Len() != 0 {
+			t.Errorf("%s oversized value should be rejected, keys=%v cost=%d", name, cache.Keys(), cache.Cost())
+		}
+	}
+}
+
+func TestWeigherDefault(t *testing.T) {
+	lru := NewLRUCache[int, int](3)
+	lfu := NewLFUCache[int, int](3)
+	for i := 0; i < 10; i++ {
+		lru.Put(i, i)
+		lfu.Put(i, i)
+	}
+	if lru.Cost() != 3 || lfu.Cost() != 3 {
+		t.Errorf("default cost should equal len, got %d, %d", lru.Cost(), lfu.Cost())
+	}
+}
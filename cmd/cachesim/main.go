@@ -0,0 +1,155 @@
+/*
+	cachesim 读取访问trace，在不同容量下回放到cache包的各个淘汰策略，对比命中率
+	usage:
+		go run ./cmd/cachesim -trace access.log -format csv -capacities 100,1000,10000
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/qieguo2016/data_structure/cache"
+)
+
+// policy 回放使用的缓存
+type policy = cache.Cache[string, int64]
+
+// policies 参与对比的淘汰策略，value保存访问的大小
+var policies = map[string]func(capacity int) policy{
+	"lru":     func(capacity int) policy { return cache.NewLRUCache[string, int64](capacity) },
+	"lfu":     func(capacity int) policy { return cache.NewLFUCache[string, int64](capacity) },
+	"arc":     func(capacity int) policy { return cache.NewARCCache[string, int64](capacity) },
+	"tinylfu": func(capacity int) policy { return cache.NewTinyLFUCache[string, int64](capacity) },
+	"slru":    func(capacity int) policy { return cache.NewSLRUCache[string, int64](capacity, 0.8) },
+	"lruk":    func(capacity int) policy { return cache.NewLRUKCache[string, int64](capacity, 2, capacity) },
+	"clock":   func(capacity int) policy { return cache.NewClockCache[string, int64](capacity) },
+	"sieve":   func(capacity int) policy { return cache.NewSIEVECache[string, int64](capacity) },
+	"s3fifo":  func(capacity int) policy { return cache.NewS3FIFOCache[string, int64](capacity) },
+}
+
+// result 一次回放的结果
+type result struct {
+	policy    string
+	capacity  int
+	hits      int
+	requests  int
+	hitBytes  int64
+	totalByte int64
+	elapsed   time.Duration
+}
+
+// replay 回放trace，未命中时写入缓存
+func replay(name string, capacity int, trace []access) result {
+	c := policies[name](capacity)
+	r := result{policy: name, capacity: capacity, requests: len(trace)}
+	start := time.Now()
+	for _, a := range trace {
+		r.totalByte += a.size
+		if _, ok := c.Get(a.key); ok {
+			r.hits++
+			r.hitBytes += a.size
+			continue
+		}
+		c.Put(a.key, a.size)
+	}
+	r.elapsed = time.Since(start)
+	return r
+}
+
+// parseCapacities 解析逗号分隔的容量列表
+func parseCapacities(s string) ([]int, error) {
+	var capacities []int
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid capacity %q", field)
+		}
+		capacities = append(capacities, n)
+	}
+	return capacities, nil
+}
+
+// parsePolicies 解析逗号分隔的策略列表，all表示全部
+func parsePolicies(s string) ([]string, error) {
+	var names []string
+	if s == "all" {
+		for name := range policies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := policies[name]; !ok {
+			return nil, fmt.Errorf("unknown policy %q", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func main() {
+	tracePath := flag.String("trace", "", "trace file path")
+	format := flag.String("format", "keys", "trace format: keys, csv or arc")
+	capacityList := flag.String("capacities", "100,1000,10000", "comma separated cache capacities (entries)")
+	policyList := flag.String("policies", "all", "comma separated policies, or all")
+	flag.Parse()
+
+	if *tracePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	capacities, err := parseCapacities(*capacityList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	names, err := parsePolicies(*policyList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*tracePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	trace, err := parseTrace(f, *format)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("trace %s: %d requests\n\n", *tracePath, len(trace))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "policy\tcapacity\thit ratio\tbyte hit ratio\tops/s\t")
+	for _, capacity := range capacities {
+		for _, name := range names {
+			r := replay(name, capacity, trace)
+			fmt.Fprintf(w, "%s\t%d\t%.4f\t%.4f\t%.0f\t\n",
+				r.policy, r.capacity,
+				ratio(float64(r.hits), float64(r.requests)),
+				ratio(float64(r.hitBytes), float64(r.totalByte)),
+				ratio(float64(r.requests), r.elapsed.Seconds()))
+		}
+	}
+	w.Flush()
+}
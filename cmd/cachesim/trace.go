@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// arcBlockSize ARC trace中每个block的字节数
+const arcBlockSize = 512
+
+// arcMaxBlocks ARC trace单行最多展开的block数，避免一行坏数据生成巨大的访问序列
+const arcMaxBlocks = 1 << 20
+
+// access 一次访问
+type access struct {
+	key  string
+	size int64
+}
+
+// parseTrace 按格式解析trace
+//   - keys: 每行一个key，大小记为1
+//   - csv: 每行 key,size
+//   - arc: ARC论文使用的trace格式，每行 起始block 连续block数 忽略 请求序号，每个block是一次访问
+func parseTrace(r io.Reader, format string) ([]access, error) {
+	switch format {
+	case "keys", "csv", "arc":
+	default:
+		return nil, fmt.Errorf("unknown trace format %q", format)
+	}
+	var trace []access
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch format {
+		case "keys":
+			trace = append(trace, access{key: line, size: 1})
+		case "csv":
+			fields := strings.Split(line, ",")
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: expect key,size, got %q", lineNo, line)
+			}
+			size, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("line %d: invalid size %q", lineNo, fields[1])
+			}
+			trace = append(trace, access{key: strings.TrimSpace(fields[0]), size: size})
+		case "arc":
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: expect start count ignore reqno, got %q", lineNo, line)
+			}
+			start, err := strconv.ParseUint(fields[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid start block %q", lineNo, fields[0])
+			}
+			count, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid block count %q", lineNo, fields[1])
+			}
+			if count > arcMaxBlocks {
+				return nil, fmt.Errorf("line %d: block count %d exceeds %d", lineNo, count, arcMaxBlocks)
+			}
+			for i := uint64(0); i < count; i++ {
+				trace = append(trace, access{key: strconv.FormatUint(start+i, 10), size: arcBlockSize})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
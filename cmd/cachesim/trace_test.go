@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTrace(t *testing.T) {
+	cases := []struct {
+		format string
+		input  string
+		expect []access
+	}{
+		{"keys", "a\n\nb\n# comment\na\n", []access{{"a", 1}, {"b", 1}, {"a", 1}}},
+		{"csv", "a,10\nb, 20\n", []access{{"a", 10}, {"b", 20}}},
+		{"arc", "100 2 0 1\n7 1 0 2\n", []access{{"100", 512}, {"101", 512}, {"7", 512}}},
+	}
+	for _, c := range cases {
+		trace, err := parseTrace(strings.NewReader(c.input), c.format)
+		if err != nil {
+			t.Fatalf("%s parse error: %v", c.format, err)
+		}
+		if !reflect.DeepEqual(trace, c.expect) {
+			t.Errorf("%s expect %v, got %v", c.format, c.expect, trace)
+		}
+	}
+
+	for format, input := range map[string]string{"csv": "a\n", "arc": "x 1 0 0\n", "xml": "a\n", "yaml": "", "json": "# comment\n"} {
+		if _, err := parseTrace(strings.NewReader(input), format); err == nil {
+			t.Errorf("%s %q should fail", format, input)
+		}
+	}
+}
+
+func TestParseTraceARCBlockLimit(t *testing.T) {
+	if _, err := parseTrace(strings.NewReader("0 99999999999 0 1\n"), "arc"); err == nil {
+		t.Errorf("huge block count should fail")
+	}
+	trace, err := parseTrace(strings.NewReader("0 1048576 0 1\n"), "arc")
+	if err != nil || len(trace) != arcMaxBlocks {
+		t.Errorf("block count at limit expect %d accesses, got %d, %v", arcMaxBlocks, len(trace), err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	trace := []access{{"a", 10}, {"b", 30}, {"a", 10}, {"b", 30}, {"c", 50}}
+	for name := range policies {
+		r := replay(name, 10, trace)
+		if r.hits != 2 || r.hitBytes != 40 || r.totalByte != 130 {
+			t.Errorf("%s expect 2 hits 40 bytes of 130, got %+v", name, r)
+		}
+	}
+}
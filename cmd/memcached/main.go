@@ -0,0 +1,67 @@
+/*
+	memcached 基于cache包分片缓存的memcached文本协议服务，用于本地集成测试
+	支持get、gets、set、add、replace、cas、delete、incr、decr、touch、flush_all、stats、version、quit
+	usage:
+		go run ./cmd/memcached -listen 127.0.0.1:11211 -capacity 100000 -policy tinylfu
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/qieguo2016/data_structure/cache"
+)
+
+// policies 可选的淘汰策略
+var policies = map[string]cache.Policy{
+	"lru":     cache.PolicyLRU,
+	"lfu":     cache.PolicyLFU,
+	"arc":     cache.PolicyARC,
+	"tinylfu": cache.PolicyTinyLFU,
+	"slru":    cache.PolicySLRU,
+	"lruk":    cache.PolicyLRUK,
+	"clock":   cache.PolicyClock,
+	"sieve":   cache.PolicySIEVE,
+	"s3fifo":  cache.PolicyS3FIFO,
+}
+
+func policyNames() string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func main() {
+	addr := flag.String("listen", "127.0.0.1:11211", "listen address")
+	capacity := flag.Int("capacity", 100000, "max number of items")
+	shards := flag.Int("shards", 16, "number of cache shards")
+	policyName := flag.String("policy", "lru", "eviction policy: "+policyNames())
+	maxItemSize := flag.Int("max-item-size", 1<<20, "max value size in bytes")
+	flag.Parse()
+
+	policy, ok := policies[*policyName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown policy %q, expect one of %s\n", *policyName, policyNames())
+		os.Exit(2)
+	}
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	log.Printf("memcached listening on %s, policy %s, capacity %d", l.Addr(), *policyName, *capacity)
+	s := newServer(*shards, *capacity, policy, *policyName, *maxItemSize)
+	if err := s.serve(l); err != nil {
+		log.Fatal(err)
+	}
+}
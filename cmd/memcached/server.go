@@ -0,0 +1,545 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qieguo2016/data_structure/cache"
+)
+
+const (
+	version      = "1.6.0-data_structure"
+	maxKeyLength = 250
+	maxLineSize  = 64 * 1024
+	relativeTime = 30 * 24 * 3600 // exptime不超过30天时是相对时间，否则是unix时间戳
+)
+
+var errLineTooLong = errors.New("line too long")
+
+// item 缓存的value，写入后不再修改，更新时整体替换，所以读操作不需要加锁
+type item struct {
+	flags    uint32
+	expireAt int64 // unix秒，0表示不过期
+	cas      uint64
+	data     []byte
+}
+
+// counters 协议层的统计，和memcached的stats字段对应
+type counters struct {
+	currConns   atomic.Int64
+	totalConns  atomic.Uint64
+	cmdGet      atomic.Uint64
+	cmdSet      atomic.Uint64
+	cmdTouch    atomic.Uint64
+	getHits     atomic.Uint64
+	getMisses   atomic.Uint64
+	deleteHits  atomic.Uint64
+	deleteMiss  atomic.Uint64
+	incrHits    atomic.Uint64
+	incrMisses  atomic.Uint64
+	decrHits    atomic.Uint64
+	decrMisses  atomic.Uint64
+	casHits     atomic.Uint64
+	casMisses   atomic.Uint64
+	casBadval   atomic.Uint64
+	touchHits   atomic.Uint64
+	touchMisses atomic.Uint64
+}
+
+// server memcached文本协议服务
+// 单个key的Get/Put由ShardedCache保证并发安全，add/replace/cas/incr这类先读后写的命令再按key加分段锁保证原子性
+type server struct {
+	cache       *cache.ShardedCache[string, *item]
+	policy      string
+	capacity    int
+	maxItemSize int
+	seed        maphash.Seed
+	locks       [256]sync.Mutex
+	casSeq      atomic.Uint64
+	start       time.Time
+	now         func() time.Time
+	stats       counters
+}
+
+// newServer constructor
+func newServer(shards, capacity int, policy cache.Policy, policyName string, maxItemSize int) *server {
+	return &server{
+		cache:       cache.NewShardedCache[string, *item](shards, capacity, policy),
+		policy:      policyName,
+		capacity:    capacity,
+		maxItemSize: maxItemSize,
+		seed:        maphash.MakeSeed(),
+		start:       time.Now(),
+		now:         time.Now,
+	}
+}
+
+// lock 锁住key所在的分段
+func (s *server) lock(key string) *sync.Mutex {
+	mu := &s.locks[maphash.String(s.seed, key)%uint64(len(s.locks))]
+	mu.Lock()
+	return mu
+}
+
+// expireAt 把协议中的exptime换算成unix秒，负数表示立即过期
+func (s *server) expireAt(exptime int64) int64 {
+	switch {
+	case exptime == 0:
+		return 0
+	case exptime < 0:
+		return -1
+	case exptime <= relativeTime:
+		return s.now().Unix() + exptime
+	default:
+		return exptime
+	}
+}
+
+// expired 判断item是否已经过期
+func (s *server) expired(it *item) bool {
+	return it.expireAt != 0 && it.expireAt <= s.now().Unix()
+}
+
+// get 读命令查找未过期的item，过期的加锁之后顺带删除
+func (s *server) get(key string) *item {
+	it, ok := s.cache.Get(key)
+	if !ok {
+		return nil
+	}
+	if s.expired(it) {
+		mu := s.lock(key)
+		if cur, ok := s.cache.Peek(key); ok && cur == it {
+			s.cache.Delete(key)
+		}
+		mu.Unlock()
+		return nil
+	}
+	return it
+}
+
+// current 写命令在持有key的锁时查找未过期的item，不影响淘汰顺序
+func (s *server) current(key string) *item {
+	it, ok := s.cache.Peek(key)
+	if !ok {
+		return nil
+	}
+	if s.expired(it) {
+		s.cache.Delete(key)
+		return nil
+	}
+	return it
+}
+
+// store 写入item，已经过期的item直接删除旧值
+func (s *server) store(key string, it *item) {
+	if s.expired(it) {
+		s.cache.Delete(key)
+		return
+	}
+	it.cas = s.casSeq.Add(1)
+	s.cache.Put(key, it)
+}
+
+// serve 接受连接，直到listener关闭
+func (s *server) serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle 处理一个连接，支持pipeline，读缓冲区为空时才flush响应
+func (s *server) handle(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	s.stats.currConns.Add(1)
+	s.stats.totalConns.Add(1)
+	defer s.stats.currConns.Add(-1)
+
+	r := bufio.NewReaderSize(conn, maxLineSize)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			if errors.Is(err, errLineTooLong) {
+				fmt.Fprint(w, "CLIENT_ERROR line too long\r\n")
+				w.Flush()
+			}
+			return
+		}
+		if !s.dispatch(line, r, w) {
+			w.Flush()
+			return
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLine 读取一行命令，去掉结尾的\r\n
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, errLineTooLong
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// dispatch 执行一条命令，返回false表示需要关闭连接
+func (s *server) dispatch(line []byte, r *bufio.Reader, w *bufio.Writer) bool {
+	fields := bytes.Fields(line)
+	if len(fields) == 0 {
+		fmt.Fprint(w, "ERROR\r\n")
+		return true
+	}
+	args := make([]string, len(fields)-1)
+	for i, f := range fields[1:] {
+		args[i] = string(f)
+	}
+	switch cmd := string(fields[0]); cmd {
+	case "get", "gets":
+		s.cmdGet(w, args, cmd == "gets")
+	case "set", "add", "replace", "cas":
+		return s.cmdStore(r, w, cmd, args)
+	case "delete":
+		s.cmdDelete(w, args)
+	case "incr", "decr":
+		s.cmdIncr(w, args, cmd == "incr")
+	case "touch":
+		s.cmdTouch(w, args)
+	case "flush_all":
+		s.cmdFlushAll(w, args)
+	case "stats":
+		s.cmdStats(w, args)
+	case "version":
+		fmt.Fprintf(w, "VERSION %s\r\n", version)
+	case "quit":
+		return false
+	default:
+		fmt.Fprint(w, "ERROR\r\n")
+	}
+	return true
+}
+
+// noreply 去掉末尾的noreply参数
+func noreply(args []string) ([]string, bool) {
+	if n := len(args); n > 0 && args[n-1] == "noreply" {
+		return args[:n-1], true
+	}
+	return args, false
+}
+
+// reply 按noreply决定是否写出响应
+func reply(w *bufio.Writer, quiet bool, msg string) {
+	if !quiet {
+		w.WriteString(msg)
+		w.WriteString("\r\n")
+	}
+}
+
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// cmdGet get <key>*，gets额外返回cas
+func (s *server) cmdGet(w *bufio.Writer, keys []string, withCas bool) {
+	if len(keys) == 0 {
+		fmt.Fprint(w, "ERROR\r\n")
+		return
+	}
+	for _, key := range keys {
+		if !validKey(key) {
+			fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+	}
+	for _, key := range keys {
+		s.stats.cmdGet.Add(1)
+		it := s.get(key)
+		if it == nil {
+			s.stats.getMisses.Add(1)
+			continue
+		}
+		s.stats.getHits.Add(1)
+		if withCas {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, it.flags, len(it.data), it.cas)
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, it.flags, len(it.data))
+		}
+		w.Write(it.data)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("END\r\n")
+}
+
+// cmdStore <cmd> <key> <flags> <exptime> <bytes> [<cas>] [noreply]\r\n<data>\r\n
+func (s *server) cmdStore(r *bufio.Reader, w *bufio.Writer, cmd string, args []string) bool {
+	args, quiet := noreply(args)
+	want := 4
+	if cmd == "cas" {
+		want = 5
+	}
+	if len(args) != want || !validKey(args[0]) {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+	flags, err1 := strconv.ParseUint(args[1], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[2], 10, 64)
+	size, err3 := strconv.Atoi(args[3])
+	var casUnique uint64
+	var err4 error
+	if cmd == "cas" {
+		casUnique, err4 = strconv.ParseUint(args[4], 10, 64)
+	}
+	if err := errors.Join(err1, err2, err3, err4); err != nil || size < 0 {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+	if size > s.maxItemSize {
+		// 跳过数据块，保持连接可用
+		if _, err := r.Discard(size + 2); err != nil {
+			return false
+		}
+		fmt.Fprint(w, "SERVER_ERROR object too large for cache\r\n")
+		return true
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return false
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		// 数据块比声明的长，丢弃这一行剩下的内容
+		if data[size+1] != '\n' {
+			if _, err := readLine(r); err != nil {
+				return false
+			}
+		}
+		fmt.Fprint(w, "CLIENT_ERROR bad data chunk\r\n")
+		return true
+	}
+	s.stats.cmdSet.Add(1)
+	key := args[0]
+	it := &item{flags: uint32(flags), expireAt: s.expireAt(exptime), data: data[:size:size]}
+
+	mu := s.lock(key)
+	defer mu.Unlock()
+	cur := s.current(key)
+	switch cmd {
+	case "add":
+		if cur != nil {
+			reply(w, quiet, "NOT_STORED")
+			return true
+		}
+	case "replace":
+		if cur == nil {
+			reply(w, quiet, "NOT_STORED")
+			return true
+		}
+	case "cas":
+		if cur == nil {
+			s.stats.casMisses.Add(1)
+			reply(w, quiet, "NOT_FOUND")
+			return true
+		}
+		if cur.cas != casUnique {
+			s.stats.casBadval.Add(1)
+			reply(w, quiet, "EXISTS")
+			return true
+		}
+		s.stats.casHits.Add(1)
+	}
+	s.store(key, it)
+	reply(w, quiet, "STORED")
+	return true
+}
+
+// cmdDelete delete <key> [noreply]
+func (s *server) cmdDelete(w *bufio.Writer, args []string) {
+	args, quiet := noreply(args)
+	if len(args) != 1 || !validKey(args[0]) {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	key := args[0]
+	mu := s.lock(key)
+	defer mu.Unlock()
+	if s.current(key) == nil {
+		s.stats.deleteMiss.Add(1)
+		reply(w, quiet, "NOT_FOUND")
+		return
+	}
+	s.cache.Delete(key)
+	s.stats.deleteHits.Add(1)
+	reply(w, quiet, "DELETED")
+}
+
+// cmdIncr incr|decr <key> <value> [noreply]，incr溢出时回绕，decr最小减到0
+func (s *server) cmdIncr(w *bufio.Writer, args []string, incr bool) {
+	args, quiet := noreply(args)
+	if len(args) != 2 || !validKey(args[0]) {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprint(w, "CLIENT_ERROR invalid numeric delta argument\r\n")
+		return
+	}
+	hits, misses := &s.stats.decrHits, &s.stats.decrMisses
+	if incr {
+		hits, misses = &s.stats.incrHits, &s.stats.incrMisses
+	}
+	key := args[0]
+	mu := s.lock(key)
+	defer mu.Unlock()
+	cur := s.current(key)
+	if cur == nil {
+		misses.Add(1)
+		reply(w, quiet, "NOT_FOUND")
+		return
+	}
+	n, err := strconv.ParseUint(string(cur.data), 10, 64)
+	if err != nil {
+		fmt.Fprint(w, "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+		return
+	}
+	switch {
+	case incr:
+		n += delta
+	case delta > n:
+		n = 0
+	default:
+		n -= delta
+	}
+	hits.Add(1)
+	data := strconv.AppendUint(nil, n, 10)
+	s.store(key, &item{flags: cur.flags, expireAt: cur.expireAt, data: data})
+	reply(w, quiet, string(data))
+}
+
+// cmdTouch touch <key> <exptime> [noreply]
+func (s *server) cmdTouch(w *bufio.Writer, args []string) {
+	args, quiet := noreply(args)
+	if len(args) != 2 || !validKey(args[0]) {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	exptime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprint(w, "CLIENT_ERROR invalid exptime argument\r\n")
+		return
+	}
+	s.stats.cmdTouch.Add(1)
+	key := args[0]
+	mu := s.lock(key)
+	defer mu.Unlock()
+	cur := s.current(key)
+	if cur == nil {
+		s.stats.touchMisses.Add(1)
+		reply(w, quiet, "NOT_FOUND")
+		return
+	}
+	s.stats.touchHits.Add(1)
+	s.store(key, &item{flags: cur.flags, expireAt: s.expireAt(exptime), data: cur.data})
+	reply(w, quiet, "TOUCHED")
+}
+
+// cmdFlushAll flush_all [delay] [noreply]，delay秒之后清空所有key
+func (s *server) cmdFlushAll(w *bufio.Writer, args []string) {
+	args, quiet := noreply(args)
+	var delay int64
+	if len(args) > 1 {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	if len(args) == 1 {
+		var err error
+		if delay, err = strconv.ParseInt(args[0], 10, 64); err != nil || delay < 0 {
+			fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+	}
+	if delay == 0 {
+		s.flush()
+	} else {
+		time.AfterFunc(time.Duration(delay)*time.Second, s.flush)
+	}
+	reply(w, quiet, "OK")
+}
+
+// flush 清空所有key
+func (s *server) flush() {
+	for _, key := range s.cache.Keys() {
+		s.cache.Delete(key)
+	}
+}
+
+// cmdStats stats，只支持通用统计
+func (s *server) cmdStats(w *bufio.Writer, args []string) {
+	if len(args) > 0 {
+		fmt.Fprint(w, "ERROR\r\n")
+		return
+	}
+	now := s.now()
+	cs := s.cache.Stats()
+	stat := func(name string, value any) {
+		fmt.Fprintf(w, "STAT %s %v\r\n", name, value)
+	}
+	stat("pid", os.Getpid())
+	stat("uptime", int64(now.Sub(s.start).Seconds()))
+	stat("time", now.Unix())
+	stat("version", version)
+	stat("policy", s.policy)
+	stat("curr_connections", s.stats.currConns.Load())
+	stat("total_connections", s.stats.totalConns.Load())
+	stat("cmd_get", s.stats.cmdGet.Load())
+	stat("cmd_set", s.stats.cmdSet.Load())
+	stat("cmd_touch", s.stats.cmdTouch.Load())
+	stat("get_hits", s.stats.getHits.Load())
+	stat("get_misses", s.stats.getMisses.Load())
+	stat("delete_hits", s.stats.deleteHits.Load())
+	stat("delete_misses", s.stats.deleteMiss.Load())
+	stat("incr_hits", s.stats.incrHits.Load())
+	stat("incr_misses", s.stats.incrMisses.Load())
+	stat("decr_hits", s.stats.decrHits.Load())
+	stat("decr_misses", s.stats.decrMisses.Load())
+	stat("cas_hits", s.stats.casHits.Load())
+	stat("cas_misses", s.stats.casMisses.Load())
+	stat("cas_badval", s.stats.casBadval.Load())
+	stat("touch_hits", s.stats.touchHits.Load())
+	stat("touch_misses", s.stats.touchMisses.Load())
+	stat("curr_items", s.cache.Len())
+	stat("total_items", cs.Puts)
+	stat("evictions", cs.Evictions)
+	stat("limit_maxitems", s.capacity)
+	w.WriteString("END\r\n")
+}
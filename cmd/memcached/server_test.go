@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qieguo2016/data_structure/cache"
+)
+
+// testConn 通过net.Pipe连到server的客户端
+type testConn struct {
+	t *testing.T
+	c net.Conn
+	r *bufio.Reader
+}
+
+func dial(t *testing.T, s *server) *testConn {
+	client, conn := net.Pipe()
+	go s.handle(conn)
+	t.Cleanup(func() { client.Close() })
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	return &testConn{t: t, c: client, r: bufio.NewReader(client)}
+}
+
+// do 发送请求，读取lines行响应，req为空时只读取
+func (c *testConn) do(req string, lines int) string {
+	c.t.Helper()
+	if req != "" {
+		if _, err := c.c.Write([]byte(req)); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	var sb strings.Builder
+	for i := 0; i < lines; i++ {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("request %q: %v", req, err)
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+func (c *testConn) expect(req string, want string) {
+	c.t.Helper()
+	if got := c.do(req, strings.Count(want, "\n")); got != want {
+		c.t.Errorf("request %q\nexpect %q\ngot    %q", req, want, got)
+	}
+}
+
+func TestServerStorage(t *testing.T) {
+	s := newServer(4, 100, cache.PolicyLRU, "lru", 1024)
+	c := dial(t, s)
+	c.expect("set a 5 0 3\r\nabc\r\n", "STORED\r\n")
+	c.expect("get a b\r\n", "VALUE a 5 3\r\nabc\r\nEND\r\n")
+	c.expect("add a 0 0 1\r\nx\r\n", "NOT_STORED\r\n")
+	c.expect("replace b 0 0 1\r\nx\r\n", "NOT_STORED\r\n")
+	c.expect("add b 0 0 1\r\nx\r\n", "STORED\r\n")
+	c.expect("replace b 1 0 2\r\nyz\r\n", "STORED\r\n")
+	c.expect("get b\r\n", "VALUE b 1 2\r\nyz\r\nEND\r\n")
+
+	// cas
+	resp := c.do("gets a\r\n", 3)
+	var cas uint64
+	fmt.Sscanf(resp, "VALUE a 5 3 %d", &cas)
+	c.expect(fmt.Sprintf("cas a 0 0 1 %d\r\nq\r\n", cas+100), "EXISTS\r\n")
+	c.expect(fmt.Sprintf("cas a 0 0 1 %d\r\nq\r\n", cas), "STORED\r\n")
+	c.expect(fmt.Sprintf("cas a 0 0 1 %d\r\nr\r\n", cas), "EXISTS\r\n")
+	c.expect("cas zz 0 0 1 1\r\nr\r\n", "NOT_FOUND\r\n")
+
+	c.expect("delete a\r\n", "DELETED\r\n")
+	c.expect("delete a\r\n", "NOT_FOUND\r\n")
+	c.expect("set big 0 0 2000\r\n"+strings.Repeat("x", 2000)+"\r\n", "SERVER_ERROR object too large for cache\r\n")
+	c.expect("set bad 0 0 1\r\nxyz\r\n", "CLIENT_ERROR bad data chunk\r\n")
+	// noreply和pipeline
+	c.expect("set n 0 0 1 noreply\r\n1\r\nget n\r\n", "VALUE n 0 1\r\n1\r\nEND\r\n")
+	c.expect("bogus\r\n", "ERROR\r\n")
+}
+
+func TestServerIncrTouchFlush(t *testing.T) {
+	s := newServer(4, 100, cache.PolicyTinyLFU, "tinylfu", 1024)
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	c := dial(t, s)
+	c.expect("incr n 1\r\n", "NOT_FOUND\r\n")
+	c.expect("set n 0 0 2\r\n10\r\n", "STORED\r\n")
+	c.expect("incr n 5\r\n", "15\r\n")
+	c.expect("decr n 20\r\n", "0\r\n")
+	c.expect("incr n 18446744073709551615\r\n", "18446744073709551615\r\n")
+	c.expect("incr n 2\r\n", "1\r\n")
+	c.expect("set s 0 0 1\r\nx\r\n", "STORED\r\n")
+	c.expect("incr s 1\r\n", "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+
+	// 过期和touch
+	c.expect("set e 0 10 1\r\nx\r\n", "STORED\r\n")
+	c.expect("touch e 100\r\n", "TOUCHED\r\n")
+	now = now.Add(50 * time.Second)
+	c.expect("get e\r\n", "VALUE e 0 1\r\nx\r\nEND\r\n")
+	now = now.Add(60 * time.Second)
+	c.expect("get e\r\n", "END\r\n")
+	c.expect("touch e 100\r\n", "NOT_FOUND\r\n")
+	c.expect("set e 0 -1 1\r\nx\r\n", "STORED\r\n")
+	c.expect("get e\r\n", "END\r\n")
+
+	c.expect("flush_all\r\n", "OK\r\n")
+	c.expect("get n s\r\n", "END\r\n")
+
+	resp := c.do("stats\r\n", 1)
+	for !strings.HasSuffix(resp, "END\r\n") {
+		resp += c.do("", 1)
+	}
+	for _, want := range []string{"STAT policy tinylfu\r\n", "STAT curr_items 0\r\n", "STAT incr_hits 3\r\n", "STAT incr_misses 1\r\n", "STAT decr_hits 1\r\n"} {
+		if !strings.Contains(resp, want) {
+			t.Errorf("stats should contain %q, got\n%s", want, resp)
+		}
+	}
+}
+
+func TestServerListen(t *testing.T) {
+	s := newServer(4, 100, cache.PolicyS3FIFO, "s3fifo", 1024)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	done := make(chan error)
+	go func() { done <- s.serve(l) }()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testConn{t: t, c: conn, r: bufio.NewReader(conn)}
+	c.expect("set k 0 0 1\r\nv\r\nget k\r\n", "STORED\r\nVALUE k 0 1\r\nv\r\nEND\r\n")
+	c.expect("quit\r\n", "")
+	conn.Close()
+	l.Close()
+	if err := <-done; err != nil {
+		t.Errorf("serve should return nil after close, got %v", err)
+	}
+}
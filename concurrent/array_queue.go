@@ -7,25 +7,31 @@
 package concurrent
 
 import (
+	"context"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	//"unsafe"
 )
 
 /* ArrayQueue
  * ringBuffer: fix length array with an increase index, use index mod cap to locate
- * availableBuffer: to mark data which can be read, when writing process is finished, is set to true
+ * availableBuffer: sequence of each slot, slot i of round r can be written when it is r*cap+i, and read when it is r*cap+i+1
  * capability: equal to 2^n, so that bit operation can be use
+ * waiters/changed: Enqueue and Dequeue park on changed when queue is full or empty, any successful operation closes it to wake them up
  */
 type ArrayQueue struct {
 	ringBuffer      []*ArrayQueueNode
-	availableBuffer []int32
+	availableBuffer []int64
 	//writeCursor     *ArrayQueueCursor
 	//readCursor      *ArrayQueueCursor
 	writeCursor int64
 	readCursor  int64
 	capability  int64
 	indexMark   int64
+	waiters     int32
+	mu          sync.Mutex
+	changed     chan struct{}
 }
 
 // ArrayQueueNode
@@ -47,17 +53,29 @@ type ArrayQueueNode struct {
 //	cursor[0] = val
 //}
 
-func NewArrayQueue() *ArrayQueue {
-	cap := int64(16) // 2 ^ n
+// arrayQueueSpins 阻塞操作挂起之前让出CPU的次数
+const arrayQueueSpins = 16
+
+// NewArrayQueue capacity向上取整到2的n次方
+func NewArrayQueue(capacity int) *ArrayQueue {
+	cap := int64(1) // 2 ^ n
+	for cap < int64(capacity) {
+		cap <<= 1
+	}
+	available := make([]int64, cap)
+	for i := range available {
+		available[i] = int64(i)
+	}
 	return &ArrayQueue{
 		ringBuffer:      make([]*ArrayQueueNode, cap),
-		availableBuffer: make([]int32, cap),
+		availableBuffer: available,
 		//writeCursor:     &ArrayQueueCursor{},
 		//readCursor:      &ArrayQueueCursor{},
 		writeCursor: int64(0),
 		readCursor:  int64(0),
 		capability:  cap,
 		indexMark:   cap - int64(1),
+		changed:     make(chan struct{}),
 	}
 }
 
@@ -89,71 +107,157 @@ func (queue *ArrayQueue) casReadCursor(oldV, newV int64) bool {
 	return atomic.CompareAndSwapInt64(&queue.readCursor, oldV, newV)
 }
 
-// Enqueue 补充写写读读4并发时序图
-func (queue *ArrayQueue) Enqueue(val interface{}) bool {
-	// 申请空间, if writeCursor < readCursor+capability-2 还可插入，否则block（condition）
-	//     PS:  writeCursor == readCursor+capability-2，此时writeCursor对应的位置还可能在读，不能更新
+// Len 元素个数，并发读写时只是一个近似值
+func (queue *ArrayQueue) Len() int {
+	n := queue.loadWriteCursor() - queue.loadReadCursor()
+	return int(max(0, min(n, queue.capability)))
+}
+
+// Cap 容量
+func (queue *ArrayQueue) Cap() int {
+	return int(queue.capability)
+}
+
+// waitSlot 等待槽位的序号变成seq，拿到游标之后对方可能还没读完或者写完，只需要短暂等待
+func (queue *ArrayQueue) waitSlot(index, seq int64) {
+	for atomic.LoadInt64(&queue.availableBuffer[index]) != seq {
+		runtime.Gosched()
+	}
+}
+
+// TryEnqueue 非阻塞入队，队列已满时直接返回false
+func (queue *ArrayQueue) TryEnqueue(val interface{}) bool {
+	// 申请空间, if writeCursor < readCursor+capability 还可插入，否则返回false
 	// cas 更新writeCursor，解决写写冲突
+	// 等待available buffer等于writeCursor，上一轮的读者可能还没读完，解决读写冲突
 	// 写ring buffer，写独享不需要cas
-	// cas 更新available buffer，解决读写冲突
+	// 更新available buffer为writeCursor+1，通知读者可读
 
 	var index, rc, wc int64
 	for {
 		rc = queue.loadReadCursor()
 		wc = queue.loadWriteCursor()
-		if wc-rc >= queue.capability-2 {
-			runtime.Gosched()
-			continue
+		if wc-rc >= queue.capability {
+			return false
 		}
 		if queue.casWriteCursor(wc, wc+1) {
 			break
 		}
 	}
 	index = wc & queue.indexMark
+	queue.waitSlot(index, wc)
 	if buf := queue.ringBuffer[index]; buf != nil {
 		buf.Value = val
 	} else {
 		queue.ringBuffer[index] = &ArrayQueueNode{Value: val}
 	}
-	for {
-		if atomic.CompareAndSwapInt32(&queue.availableBuffer[index], 0, 1) {
-			break
-		}
-	}
+	atomic.StoreInt64(&queue.availableBuffer[index], wc+1)
+	queue.notify()
 	return true
 }
 
-func (queue *ArrayQueue) Dequeue() interface{} {
-	// 判断可读，if readCursor < writeCursor 可读，否则已空block
+// TryDequeue 队列为空时直接返回false，不等待
+func (queue *ArrayQueue) TryDequeue() (interface{}, bool) {
+	// 判断可读，if readCursor < writeCursor 可读，否则返回false
 	// cas 更新readCursor，解决读读冲突
-	// 再判断available buffer是否true，true可读，否则自旋，解决读写冲突
+	// 再判断available buffer是否等于readCursor+1，否则等待写者写完，解决读写冲突
 	// 读ringbuffer，读独享不需要cas
-	// 更新available buffer为false，不需要cas？
+	// 更新available buffer为下一轮的writeCursor，通知写者可写
 	var index, rc, wc int64
 	for {
 		rc = queue.loadReadCursor()
 		wc = queue.loadWriteCursor()
 		if rc >= wc {
-			runtime.Gosched()
-			continue
+			return nil, false
 		}
 		if queue.casReadCursor(rc, rc+1) {
 			break
 		}
 	}
 	index = rc & queue.indexMark
-	for {
-		if atomic.LoadInt32(&queue.availableBuffer[index]) == 1 {
-			break
-		}
-	}
+	queue.waitSlot(index, rc+1)
 
 	buf := queue.ringBuffer[index]
 	val := buf.Value
-	for {
-		if atomic.CompareAndSwapInt32(&queue.availableBuffer[index], 1, 0) {
-			break
+	buf.Value = nil
+	atomic.StoreInt64(&queue.availableBuffer[index], rc+queue.capability)
+	queue.notify()
+	return val, true
+}
+
+// wait 退避等待ready：前几次只让出CPU，之后挂起到changed上，直到有元素入队或者出队、或者ctx结束
+func (queue *ArrayQueue) wait(ctx context.Context, attempt int, ready func() bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if attempt < arrayQueueSpins {
+		runtime.Gosched()
+		return nil
+	}
+	queue.mu.Lock()
+	ch := queue.changed
+	atomic.AddInt32(&queue.waiters, 1)
+	queue.mu.Unlock()
+	defer atomic.AddInt32(&queue.waiters, -1)
+	// 计数之后再检查一次，否则在检查和计数之间发生的入队或出队不会唤醒这里
+	if ready() {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify 有等待者时唤醒全部等待者，没有等待者时只有一次原子读
+func (queue *ArrayQueue) notify() {
+	if atomic.LoadInt32(&queue.waiters) == 0 {
+		return
+	}
+	queue.mu.Lock()
+	close(queue.changed)
+	queue.changed = make(chan struct{})
+	queue.mu.Unlock()
+}
+
+// EnqueueContext 队列已满时等待，直到入队成功或者ctx结束
+func (queue *ArrayQueue) EnqueueContext(ctx context.Context, val interface{}) error {
+	notFull := func() bool {
+		return queue.loadWriteCursor()-queue.loadReadCursor() < queue.capability
+	}
+	for attempt := 0; !queue.TryEnqueue(val); attempt++ {
+		if err := queue.wait(ctx, attempt, notFull); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// DequeueContext 队列为空时等待，直到出队成功或者ctx结束
+func (queue *ArrayQueue) DequeueContext(ctx context.Context) (interface{}, error) {
+	notEmpty := func() bool {
+		return queue.loadReadCursor() < queue.loadWriteCursor()
+	}
+	for attempt := 0; ; attempt++ {
+		if val, ok := queue.TryDequeue(); ok {
+			return val, nil
+		}
+		if err := queue.wait(ctx, attempt, notEmpty); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Enqueue 队列已满时阻塞
+func (queue *ArrayQueue) Enqueue(val interface{}) bool {
+	queue.EnqueueContext(context.Background(), val)
+	return true
+}
+
+// Dequeue 队列为空时阻塞
+func (queue *ArrayQueue) Dequeue() interface{} {
+	val, _ := queue.DequeueContext(context.Background())
 	return val
 }
@@ -1,6 +1,8 @@
 package concurrent
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"sync"
@@ -183,7 +185,7 @@ func TestArrayQueue(t *testing.T) {
 
 	//wg := sync.WaitGroup{}
 	c := make(chan []int, 1000)
-	q := NewArrayQueue()
+	q := NewArrayQueue(16)
 	concurrency := 10 // 并发
 	iterations := 20 // 单个并发执行数量
 
@@ -228,4 +230,84 @@ func TestArrayQueue(t *testing.T) {
 		fmt.Println(k, ret[k])
 	}
 	fmt.Println("==== end ====")
-}
\ No newline at end of file
+}
+
+func TestArrayQueueTry(t *testing.T) {
+	q := NewArrayQueue(3)
+	if q.Cap() != 4 {
+		t.Fatalf("cap expect 4, got %d", q.Cap())
+	}
+	for i := 0; i < 4; i++ {
+		if !q.TryEnqueue(i) {
+			t.Fatalf("enqueue %d failed", i)
+		}
+	}
+	if q.TryEnqueue(4) || q.Len() != 4 {
+		t.Fatalf("enqueue full expect false, len %d", q.Len())
+	}
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			if v, ok := q.TryDequeue(); !ok || v != round*4+i {
+				t.Fatalf("dequeue expect %d, got %v %v", round*4+i, v, ok)
+			}
+			q.TryEnqueue(round*4 + i + 4)
+		}
+	}
+	for q.Len() > 0 {
+		q.TryDequeue()
+	}
+	if _, ok := q.TryDequeue(); ok {
+		t.Errorf("dequeue empty expect false")
+	}
+}
+
+func TestArrayQueueContext(t *testing.T) {
+	q := NewArrayQueue(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.DequeueContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("dequeue empty expect deadline, got %v", err)
+	}
+	q.Enqueue(1)
+	q.Enqueue(2)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel2()
+	if err := q.EnqueueContext(ctx2, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("enqueue full expect deadline, got %v", err)
+	}
+
+	// 挂起的读者被写者唤醒
+	q = NewArrayQueue(4)
+	const producers, items = 4, 2000
+	var wg sync.WaitGroup
+	sum := make(chan int, producers)
+	for n := 0; n < producers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := 0
+			for i := 0; i < items; i++ {
+				s += q.Dequeue().(int)
+			}
+			sum <- s
+		}()
+	}
+	for n := 0; n < producers; n++ {
+		go func() {
+			for i := 1; i <= items; i++ {
+				q.Enqueue(i)
+				if i%500 == 0 {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	for n := 0; n < producers; n++ {
+		total += <-sum
+	}
+	if total != producers*items*(items+1)/2 {
+		t.Errorf("sum expect %d, got %d", producers*items*(items+1)/2, total)
+	}
+}